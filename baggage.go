@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"regexp"
+	"strings"
+
+	"github.com/justinm35/flyctl/money"
+	"github.com/justinm35/flyctl/types"
+)
+
+// Luggage is what each traveler brings, from "luggage" at the top level or
+// in a profile:
+//
+//	luggage: {carry_on: 1, checked: 1}
+type Luggage struct {
+	CarryOn int `mapstructure:"carry_on"`
+	Checked int `mapstructure:"checked"`
+}
+
+func (l Luggage) String() string {
+	if l == (Luggage{}) {
+		return "no bags"
+	}
+	var parts []string
+	if l.CarryOn > 0 {
+		parts = append(parts, fmt.Sprintf("%d carry-on", l.CarryOn))
+	}
+	if l.Checked > 0 {
+		parts = append(parts, fmt.Sprintf("%d checked", l.Checked))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (l Luggage) validate(key string) []configProblem {
+	if l.CarryOn < 0 || l.Checked < 0 {
+		return []configProblem{{key, "bag counts can't be negative"}}
+	}
+	if l.CarryOn > 2 || l.Checked > 5 {
+		return []configProblem{{key, fmt.Sprintf("at most 2 carry-on and 5 checked bags, got %s", l)}}
+	}
+	return nil
+}
+
+// BagFee is what a carrier charges per bag and direction beyond the fare's
+// allowance. Entries under "bag_fees" override the built-in ones by IATA
+// code:
+//
+//	bag_fees:
+//	  AC: {name: Air Canada, currency: CAD, carry_on: 0, checked: [35, 50]}
+type BagFee struct {
+	Name     string   `mapstructure:"name"`
+	Currency string   `mapstructure:"currency"`
+	CarryOn  string   `mapstructure:"carry_on"`
+	Checked  []string `mapstructure:"checked"` // the 1st, 2nd, ... bag; the last applies to any more
+}
+
+// defaultBagFees are typical standard economy fees on short and medium
+// haul routes. Real fees vary by route, fare and status.
+var defaultBagFees = map[string]BagFee{
+	"AC": {Name: "Air Canada", Currency: "CAD", CarryOn: "0", Checked: []string{"35", "50"}},
+	"WS": {Name: "WestJet", Currency: "CAD", CarryOn: "0", Checked: []string{"35", "50"}},
+	"PD": {Name: "Porter Airlines", Currency: "CAD", CarryOn: "0", Checked: []string{"35", "50"}},
+	"TS": {Name: "Air Transat", Currency: "CAD", CarryOn: "0", Checked: []string{"40", "60"}},
+	"F8": {Name: "Flair Airlines", Currency: "CAD", CarryOn: "60", Checked: []string{"60", "70"}},
+	"AA": {Name: "American", Currency: "USD", CarryOn: "0", Checked: []string{"40", "45"}},
+	"DL": {Name: "Delta", Currency: "USD", CarryOn: "0", Checked: []string{"35", "45"}},
+	"UA": {Name: "United", Currency: "USD", CarryOn: "0", Checked: []string{"40", "50"}},
+	"WN": {Name: "Southwest", Currency: "USD", CarryOn: "0", Checked: []string{"35", "45"}},
+	"B6": {Name: "JetBlue", Currency: "USD", CarryOn: "0", Checked: []string{"35", "50"}},
+	"AS": {Name: "Alaska", Currency: "USD", CarryOn: "0", Checked: []string{"35", "45"}},
+	"NK": {Name: "Spirit Airlines", Currency: "USD", CarryOn: "65", Checked: []string{"60", "70"}},
+	"F9": {Name: "Frontier", Currency: "USD", CarryOn: "65", Checked: []string{"60", "75"}},
+	"FR": {Name: "Ryanair", Currency: "EUR", CarryOn: "25", Checked: []string{"35", "35"}},
+	"U2": {Name: "easyJet", Currency: "GBP", CarryOn: "20", Checked: []string{"30", "40"}},
+}
+
+var carrierCode = regexp.MustCompile(`^[A-Z0-9]{2}$`)
+
+// bagFees is the built-in fee table with the configured entries on top.
+func bagFees(configured map[string]BagFee) map[string]BagFee {
+	fees := maps.Clone(defaultBagFees)
+	for code, fee := range configured {
+		// viper lowercases keys
+		fees[strings.ToUpper(code)] = fee
+	}
+	return fees
+}
+
+func validateBagFees(fees map[string]BagFee) []configProblem {
+	var problems []configProblem
+	for code, fee := range fees {
+		key := "bag_fees." + code
+		if !carrierCode.MatchString(strings.ToUpper(code)) {
+			problems = append(problems, configProblem{key, "must be keyed by a two-character IATA airline code like AC"})
+			continue
+		}
+		if !currencyCode.MatchString(fee.Currency) {
+			problems = append(problems, configProblem{key + ".currency", fmt.Sprintf("must be a three-letter ISO 4217 code like CAD, got %q", fee.Currency)})
+			continue
+		}
+		if len(fee.Checked) == 0 {
+			problems = append(problems, configProblem{key + ".checked", "needs the fee for at least the first bag"})
+		}
+		if _, _, err := fee.amounts(); err != nil {
+			problems = append(problems, configProblem{key, err.Error()})
+		}
+	}
+	return problems
+}
+
+// amounts parses the fees into minor units of the fee's currency.
+func (f BagFee) amounts() (carryOn int64, checked []int64, err error) {
+	if f.CarryOn != "" {
+		m, err := money.Parse(f.CarryOn, f.Currency)
+		if err != nil {
+			return 0, nil, fmt.Errorf("carry_on: %w", err)
+		}
+		carryOn = m.Amount
+	}
+	for i, s := range f.Checked {
+		m, err := money.Parse(s, f.Currency)
+		if err != nil {
+			return 0, nil, fmt.Errorf("checked bag %d: %w", i+1, err)
+		}
+		checked = append(checked, m.Amount)
+	}
+	return carryOn, checked, nil
+}
+
+// feeFor finds the fee table entry for the carrier flying a segment, by
+// IATA code, flight number prefix or name.
+func feeFor(fees map[string]BagFee, seg types.Segment) (BagFee, bool) {
+	if f, ok := fees[strings.ToUpper(seg.Carrier)]; ok {
+		return f, true
+	}
+	if len(seg.FlightNo) >= 2 {
+		if f, ok := fees[strings.ToUpper(seg.FlightNo[:2])]; ok {
+			return f, true
+		}
+	}
+	for _, f := range fees {
+		if strings.EqualFold(f.Name, seg.Carrier) {
+			return f, true
+		}
+	}
+	return BagFee{}, false
+}
+
+// estimateCosts prices every offer with the bags the profile travels with.
+func estimateCosts(offers []types.FlightOffer, profile Profile, fees map[string]BagFee, rates *lazyRates) []types.FlightOffer {
+	travelers := profile.Passengers.Adults + profile.Passengers.Children
+	for i := range offers {
+		offers[i].Estimate = estimateCost(offers[i], *profile.Luggage, travelers, fees, rates)
+	}
+	return offers
+}
+
+// estimateCost adds the fees for bags beyond the allowance, charged by the
+// first carrier for every seated traveler in each direction. Without a
+// known allowance, one carry-on and no checked bags are assumed.
+func estimateCost(o types.FlightOffer, luggage Luggage, travelers int, fees map[string]BagFee, rates *lazyRates) *types.Estimate {
+	price := o.Price()
+	est := &types.Estimate{
+		Total:    price,
+		BagFees:  types.Money{Currency: price.Currency},
+		Complete: o.Baggage != nil,
+		Luggage:  luggage.String(),
+	}
+	if len(o.Segments) == 0 {
+		return est
+	}
+
+	allowance := types.Baggage{CarryOn: 1}
+	if o.Baggage != nil {
+		allowance = *o.Baggage
+	}
+	extraCarryOn := max(luggage.CarryOn-allowance.CarryOn, 0)
+	if extraCarryOn == 0 && luggage.Checked <= allowance.Checked {
+		return est
+	}
+
+	fee, ok := feeFor(fees, o.Segments[0])
+	if !ok {
+		est.Complete = false
+		return est
+	}
+	est.Table = fee.Name
+	carryOn, checked, err := fee.amounts()
+	if err != nil || len(checked) == 0 {
+		slog.Warn("bag fees", "carrier", fee.Name, "err", err)
+		est.Complete = false
+		return est
+	}
+
+	perTraveler := carryOn * int64(extraCarryOn)
+	for bag := allowance.Checked + 1; bag <= luggage.Checked; bag++ {
+		perTraveler += checked[min(bag, len(checked))-1]
+	}
+	bagFees := types.Money{Amount: perTraveler * int64(travelers*directions(o)), Currency: fee.Currency}
+	if bagFees.Currency != price.Currency {
+		r, err := rates.get()
+		if err == nil {
+			bagFees, _, err = r.Convert(bagFees, price.Currency)
+		}
+		if err != nil {
+			est.Complete = false
+			return est
+		}
+	}
+
+	est.BagFees = bagFees
+	est.Total.Amount += bagFees.Amount
+	return est
+}
+
+// directions is 2 for a round trip and 1 otherwise.
+func directions(o types.FlightOffer) int {
+	if len(o.Segments) > 1 && o.Segments[len(o.Segments)-1].To == o.Segments[0].From {
+		return 2
+	}
+	return 1
+}
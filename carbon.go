@@ -0,0 +1,30 @@
+package main
+
+import (
+	"github.com/justinm35/flyctl/emissions"
+	"github.com/justinm35/flyctl/types"
+)
+
+// estimateEmissions fills in emissions for offers the provider didn't
+// report them for, where every airport on the way is known.
+func estimateEmissions(offers []types.FlightOffer, cabin string) []types.FlightOffer {
+	for i, o := range offers {
+		if o.Emissions != nil {
+			continue
+		}
+		if e, ok := emissions.Estimate(o.Segments, cabin); ok {
+			offers[i].Emissions = &e
+		}
+	}
+	return offers
+}
+
+// lowEmissions reports whether an offer emits no more than is typical for
+// its route.
+func lowEmissions(o types.FlightOffer) bool {
+	if o.Emissions == nil {
+		return false
+	}
+	diff, ok := o.Emissions.VsTypical()
+	return ok && diff <= 0
+}
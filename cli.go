@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/justinm35/flyctl/types"
+	"github.com/justinm35/flyctl/utils"
+	"github.com/spf13/viper"
+)
+
+// lowestPriceDays is how far back `flyctl search` looks for the lowest price
+// seen on the route.
+const lowestPriceDays = 30
+
+// runSearchCommand runs `flyctl search` and prints the offers as a table,
+// without starting the TUI.
+func runSearchCommand(args []string) error {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	saved := fs.String("saved", "", "run a saved search by name, e.g. home-cph")
+	from := fs.String("from", "", "origin airport IATA code")
+	to := fs.String("to", "", "destination airport IATA code")
+	date := fs.String("date", "", "departure date, YYYY-MM-DD")
+	provider := fs.String("provider", activeProfile().provider(), "search provider: "+strings.Join(searchProviders, ", "))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	query := searchQuery{Provider: *provider}
+	if *saved != "" {
+		s, err := findSavedSearch(*saved)
+		if err != nil {
+			return err
+		}
+		query = s.Query
+	}
+
+	// flags override the saved search, so a saved route can be run on another day
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "from":
+			query.Origin = strings.ToUpper(*from)
+		case "to":
+			query.Destination = strings.ToUpper(*to)
+		case "date":
+			query.DepartureDate = *date
+		case "provider":
+			query.Provider = *provider
+		}
+	})
+	if query.Origin == "" || query.Destination == "" || query.DepartureDate == "" {
+		return fmt.Errorf("search needs --saved or all of --from, --to and --date")
+	}
+
+	if problems := missingCredentials(viper.GetViper(), query.Provider); len(problems) > 0 {
+		return joinProblems(problems)
+	}
+
+	offers, err := runSearch(context.Background(), query, newSearchSettings(query.Provider))
+	if err != nil {
+		return err
+	}
+	if err := recordSearch(query, offers, time.Now()); err != nil {
+		slog.Error("record search", "err", err)
+	}
+
+	// the table leaves out offers without segments
+	offers = slices.DeleteFunc(offers, func(o types.FlightOffer) bool { return len(o.Segments) == 0 })
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ROUTE\tDEPARTURE\tARRIVAL\tDURATION\tPRICE\tEST. TOTAL\tCO2\tCONNECTIONS\tCARRIER")
+	for i, row := range utils.FormatResponseData(offers) {
+		row[colConnections] = layoverRules.connectionSummary(offers[i])
+		fmt.Fprintln(w, strings.Join(row[1:], "\t"))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	lowest, ok, err := lowestPrice(query, activeProfile().Currency, time.Now().AddDate(0, 0, -lowestPriceDays))
+	if err != nil {
+		slog.Error("lowest price", "err", err)
+	} else if ok {
+		fmt.Printf("\nLowest %s→%s in the last %d days: %s, seen %s for %s\n", query.Origin, query.Destination,
+			lowestPriceDays, utils.FormatMoney(lowest.Price), lowest.ObservedAt.Format("Jan 2"), lowest.DepartureDate)
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/justinm35/flyctl/styles"
+	"github.com/justinm35/flyctl/types"
+	"github.com/justinm35/flyctl/utils"
+)
+
+const compareLabelWidth = 18
+
+// CompareState is the side-by-side view of the offers marked in Results.
+type CompareState struct {
+	offers   []types.FlightOffer
+	viewport viewport.Model
+}
+
+func newCompareState(keys compareKeyMap) CompareState {
+	vp := viewport.New(80, 20)
+	vp.KeyMap = keys.viewportKeyMap()
+	return CompareState{viewport: vp}
+}
+
+// compareRow is one attribute across all compared offers. best returns the
+// index of the winning offer, or -1 when the row has no notion of "better".
+type compareRow struct {
+	label string
+	value func(o types.FlightOffer) string
+	best  func(offers []types.FlightOffer) int
+}
+
+var compareRows = []compareRow{
+	{label: "Route", value: func(o types.FlightOffer) string { return routeLine(o.Segments) }},
+	{
+		label: "Departure",
+		value: func(o types.FlightOffer) string { return o.Segments[0].DepartAt.UTC().Format("Mon Jan 2, 15:04") },
+	},
+	{
+		label: "Arrival",
+		value: func(o types.FlightOffer) string {
+			return o.Segments[len(o.Segments)-1].ArriveAt.UTC().Format("Mon Jan 2, 15:04")
+		},
+		best: bestBy(func(o types.FlightOffer) int64 { return o.Segments[len(o.Segments)-1].ArriveAt.Unix() }),
+	},
+	{
+		label: "Total duration",
+		value: func(o types.FlightOffer) string { return formatDuration(totalDuration(o)) },
+		best:  bestBy(func(o types.FlightOffer) int64 { return int64(totalDuration(o)) }),
+	},
+	{
+		label: "Price",
+		value: func(o types.FlightOffer) string { return utils.FormatMoney(o.Price()) },
+		best:  bestByPrice(types.FlightOffer.Price),
+	},
+	{
+		label: "Base fare",
+		value: func(o types.FlightOffer) string {
+			if o.Fare == nil {
+				return "-"
+			}
+			return utils.FormatMoney(o.Fare.Base)
+		},
+	},
+	{
+		label: "Taxes & fees",
+		value: func(o types.FlightOffer) string {
+			if o.Fare == nil {
+				return "-"
+			}
+			if o.Fare.Fees.Currency != o.Fare.Taxes.Currency && o.Fare.Fees.Amount != 0 {
+				return fmt.Sprintf("%s + %s", utils.FormatMoney(o.Fare.Taxes), utils.FormatMoney(o.Fare.Fees))
+			}
+			taxes := types.Money{Amount: o.Fare.Taxes.Amount + o.Fare.Fees.Amount, Currency: o.Fare.Taxes.Currency}
+			return fmt.Sprintf("%s (%s)", utils.FormatMoney(taxes), share(taxes, o.TotalPrice))
+		},
+	},
+	{
+		label: "Bags included",
+		value: func(o types.FlightOffer) string {
+			if o.Baggage == nil {
+				return "-"
+			}
+			return Luggage{CarryOn: o.Baggage.CarryOn, Checked: o.Baggage.Checked}.String()
+		},
+	},
+	{
+		label: "Est. total",
+		value: func(o types.FlightOffer) string {
+			s := utils.FormatMoney(o.EstimatedTotal())
+			if o.Estimate != nil && !o.Estimate.Complete {
+				s += " +?"
+			}
+			return s
+		},
+		best: bestByPrice(types.FlightOffer.EstimatedTotal),
+	},
+	{
+		label: "CO2",
+		value: func(o types.FlightOffer) string {
+			s := utils.FormatEmissions(o.Emissions)
+			if o.Emissions != nil {
+				if diff, ok := o.Emissions.VsTypical(); ok {
+					s += " (" + utils.EmissionsBadge(diff) + ")"
+				}
+			}
+			return s
+		},
+		best: bestBy(func(o types.FlightOffer) int64 {
+			if o.Emissions == nil {
+				return math.MaxInt64
+			}
+			return int64(o.Emissions.Grams)
+		}),
+	},
+	{label: "Carriers", value: func(o types.FlightOffer) string { return utils.JoinUniqueCarriers(o.Segments) }},
+	{
+		label: "Segments",
+		value: func(o types.FlightOffer) string {
+			switch len(o.Segments) {
+			case 1:
+				return "nonstop"
+			case 2:
+				return "2 (1 stop)"
+			default:
+				return fmt.Sprintf("%d (%d stops)", len(o.Segments), len(o.Segments)-1)
+			}
+		},
+		best: bestBy(func(o types.FlightOffer) int64 { return int64(len(o.Segments)) }),
+	},
+	{
+		label: "Layovers",
+		value: func(o types.FlightOffer) string {
+			if len(o.Segments) < 2 {
+				return "-"
+			}
+			var parts []string
+			for i := 1; i < len(o.Segments); i++ {
+				layover := o.Segments[i].DepartAt.Sub(o.Segments[i-1].ArriveAt)
+				parts = append(parts, fmt.Sprintf("%s %s", o.Segments[i-1].To, formatDuration(layover)))
+			}
+			return strings.Join(parts, ", ")
+		},
+		best: bestBy(func(o types.FlightOffer) int64 { return int64(totalLayover(o)) }),
+	},
+}
+
+// bestBy picks the offer with the lowest key. Ties have no winner, so an
+// attribute that is the same everywhere is not highlighted.
+func bestBy(key func(o types.FlightOffer) int64) func(offers []types.FlightOffer) int {
+	return func(offers []types.FlightOffer) int {
+		best := -1
+		tied := false
+		for i, o := range offers {
+			if best == -1 {
+				best = i
+				continue
+			}
+			switch cmp.Compare(key(o), key(offers[best])) {
+			case -1:
+				best, tied = i, false
+			case 0:
+				tied = true
+			}
+		}
+		if tied {
+			return -1
+		}
+		return best
+	}
+}
+
+// bestByPrice picks the cheapest offer. Amounts in different currencies
+// can't be compared, so when an offer couldn't be converted there is no
+// winner.
+func bestByPrice(price func(o types.FlightOffer) types.Money) func(offers []types.FlightOffer) int {
+	byAmount := bestBy(func(o types.FlightOffer) int64 { return price(o).Amount })
+	return func(offers []types.FlightOffer) int {
+		for _, o := range offers {
+			if price(o).Currency != price(offers[0]).Currency {
+				return -1
+			}
+		}
+		return byAmount(offers)
+	}
+}
+
+func totalLayover(o types.FlightOffer) time.Duration {
+	var total time.Duration
+	for i := 1; i < len(o.Segments); i++ {
+		total += o.Segments[i].DepartAt.Sub(o.Segments[i-1].ArriveAt)
+	}
+	return total
+}
+
+// openCompare switches to the comparison screen for the marked offers.
+func (m *Model) openCompare() tea.Cmd {
+	offers := m.screenResults.comparedOffers()
+	if len(offers) < 2 {
+		return m.setStatus(fmt.Sprintf("mark 2 to %d offers with %s to compare", maxCompared, m.keys.Results.Mark.Help().Key))
+	}
+	m.compare.offers = offers
+	m.screen = screenCompare
+	m.applyLayout()
+	m.compare.viewport.GotoTop()
+	return nil
+}
+
+// resize fits the comparison to the area normally taken by the panes.
+func (c *CompareState) resize(width, height int) {
+	c.viewport.Width = width
+	c.viewport.Height = height
+	c.viewport.SetContent(renderComparison(c.offers, width))
+}
+
+func updateCompare(m Model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	if km, ok := msg.(tea.KeyMsg); ok && key.Matches(km, m.keys.Compare.Back) {
+		m.screen = screenResults
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.compare.viewport, cmd = m.compare.viewport.Update(msg)
+	return m, cmd
+}
+
+func viewCompare(m Model) string {
+	return m.compare.viewport.View()
+}
+
+// renderComparison lays the offers out in columns: a table of attributes
+// with the best value in each row highlighted, then each offer's timeline.
+func renderComparison(offers []types.FlightOffer, width int) string {
+	theme := styles.Active()
+	if len(offers) == 0 {
+		return ""
+	}
+	colWidth := max((width-compareLabelWidth)/len(offers)-2, 12)
+	label := lipgloss.NewStyle().Foreground(theme.Label).Width(compareLabelWidth)
+	cell := lipgloss.NewStyle().Width(colWidth).MarginRight(2)
+	best := cell.Foreground(theme.Good).Bold(true)
+
+	var lines []string
+	lines = append(lines, theme.Title().Render("[Compare offers]")+
+		lipgloss.NewStyle().Foreground(theme.Muted).Render("  best value in each row is highlighted"), "")
+
+	header := []string{label.Render("")}
+	for i := range offers {
+		header = append(header, cell.Inherit(theme.Title()).Render(fmt.Sprintf("Offer %d", i+1)))
+	}
+	lines = append(lines, lipgloss.JoinHorizontal(lipgloss.Top, header...))
+
+	for _, row := range compareRows {
+		winner := -1
+		if row.best != nil {
+			winner = row.best(offers)
+		}
+		cells := []string{label.Render(row.label)}
+		for i, o := range offers {
+			style := cell
+			if i == winner {
+				style = best
+			}
+			cells = append(cells, style.Render(row.value(o)))
+		}
+		lines = append(lines, lipgloss.JoinHorizontal(lipgloss.Top, cells...))
+	}
+
+	lines = append(lines, "", label.Render("Timeline"))
+	timelines := []string{label.Render("")}
+	for _, o := range offers {
+		timelines = append(timelines, cell.Render(segmentTimeline(o, colWidth)))
+	}
+	lines = append(lines, lipgloss.JoinHorizontal(lipgloss.Top, timelines...))
+
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
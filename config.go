@@ -1,53 +1,389 @@
 package main
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/justinm35/flyctl/money"
+	"github.com/justinm35/flyctl/providers/amadeus"
+	rapidgoogleflights "github.com/justinm35/flyctl/providers/rapid_google_flights"
+	"github.com/justinm35/flyctl/store"
+	"github.com/justinm35/flyctl/styles"
 	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
 )
 
-func InitConfig() error {
-	home, err := os.UserHomeDir()
+// placeholderSecret is what older versions wrote for credentials that still
+// had to be filled in.
+const placeholderSecret = "please fill in"
+
+// Config is the typed form of config.yaml. Sections with free-form keys
+// (keys, themes) are read from viper where they are used.
+type Config struct {
+	Adults            int                `mapstructure:"adults"`
+	Currency          string             `mapstructure:"currency"`
+	Provider          string             `mapstructure:"provider"`
+	Theme             string             `mapstructure:"theme"`
+	Keymap            string             `mapstructure:"keymap"`
+	Locale            string             `mapstructure:"locale"`
+	Profile           string             `mapstructure:"profile"`
+	Profiles          map[string]Profile `mapstructure:"profiles"`
+	ExchangeRates     ExchangeRates      `mapstructure:"exchange_rates"`
+	Luggage           Luggage            `mapstructure:"luggage"`
+	BagFees           map[string]BagFee  `mapstructure:"bag_fees"`
+	Layovers          LayoverRules       `mapstructure:"layovers"`
+	AmadeusAPIKey     string             `mapstructure:"amadeus_api_key"`
+	AmadeusAPISecret  string             `mapstructure:"amadeus_api_secret"`
+	RapidGoogleAPIKey string             `mapstructure:"rapid_google_api_key"`
+}
+
+// providerCredentials lists the config keys each provider needs, and where
+// to get them.
+var providerCredentials = map[string]struct {
+	keys   []string
+	signup string
+}{
+	rapidgoogleflights.ProviderName: {
+		keys:   []string{"rapid_google_api_key"},
+		signup: "https://rapidapi.com/DataCrawler/api/google-flights2",
+	},
+	amadeus.ProviderName: {
+		keys:   []string{"amadeus_api_key", "amadeus_api_secret"},
+		signup: "https://developers.amadeus.com",
+	},
+}
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// configProblem is one thing wrong with the config, phrased for the user.
+type configProblem struct {
+	key     string
+	message string
+}
+
+func (p configProblem) String() string { return p.key + ": " + p.message }
+
+// InitConfig loads config.yaml from path, or from the config directory when
+// path is empty. A missing default config is created; a missing explicit one
+// is an error.
+func InitConfig(path string) error {
+	configFile, err := configFilePath(path)
 	if err != nil {
-		return fmt.Errorf("get home dir: %w", err)
+		return err
 	}
 
-	configDir := filepath.Join(home, "/.config/flyctl")
-	configFile := filepath.Join(configDir, "config.yaml")
-
-	viper.SetConfigName("config")
-	viper.AddConfigPath(configDir)
-	viper.AddConfigPath(".")
+	viper.SetConfigFile(configFile)
 	viper.SetConfigType("yaml")
+	// FLYCTL_CURRENCY overrides currency, FLYCTL_PROFILES_ALEX_HOME overrides profiles.alex.home
+	viper.SetEnvPrefix(appName)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	viper.AutomaticEnv()
+	setConfigDefaults(viper.GetViper())
 
-	// Set default config values
-	viper.SetDefault("adults", "1")
-	viper.SetDefault("currency", "CAD")
-	viper.SetDefault("amadeus_api_key", "please fill in")
-	viper.SetDefault("amadeus_api_secret", "please fill in")
-	viper.SetDefault("rapid_google_api_key", "please fill in")
-
-	var fileLookupError viper.ConfigFileNotFoundError
 	if err := viper.ReadInConfig(); err != nil {
-		if errors.As(err, &fileLookupError) {
-			if err := os.MkdirAll(configDir, 0o755); err != nil {
-				log.Printf("Failed for some reason, %s", err.Error())
-				return fmt.Errorf("create config dir %q: %w", configDir, err)
-			}
-			if err := viper.SafeWriteConfigAs(configFile); err != nil {
-				log.Printf("Failed for some reason, %s", err.Error())
-				return fmt.Errorf("write default config to %q: %w", configFile, err)
-			}
-			return nil
-		} else {
+		if path != "" || !errors.Is(err, os.ErrNotExist) {
 			return fmt.Errorf("failed to read config from %q: %w", configFile, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(configFile), 0o700); err != nil {
+			return fmt.Errorf("create config dir %q: %w", filepath.Dir(configFile), err)
+		}
+		// a fresh viper, so secrets from FLYCTL_* variables are not written out
+		defaults := viper.New()
+		setConfigDefaults(defaults)
+		if err := defaults.SafeWriteConfigAs(configFile); err != nil {
+			return fmt.Errorf("write default config to %q: %w", configFile, err)
+		}
+		if err := os.Chmod(configFile, 0o600); err != nil {
+			return fmt.Errorf("write default config to %q: %w", configFile, err)
+		}
+		return viper.ReadInConfig()
+	}
+	return nil
+}
+
+// applyLocale sets how amounts are written: "locale" in the config if set,
+// otherwise LC_ALL, LC_MONETARY or LANG.
+func applyLocale() {
+	if name := viper.GetString("locale"); name != "" {
+		money.SetLocale(money.ParseLocale(name))
+	} else {
+		money.SetLocale(money.EnvLocale())
+	}
+}
+
+// configFilePath is the config file in use: path if given, otherwise
+// config.yaml in the config directory.
+func configFilePath(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.yaml"), nil
+}
+
+func setConfigDefaults(v *viper.Viper) {
+	v.SetDefault("adults", 1)
+	v.SetDefault("currency", "CAD")
+	v.SetDefault("provider", rapidgoogleflights.ProviderName)
+	v.SetDefault("luggage.carry_on", 1)
+	v.SetDefault("luggage.checked", 0)
+	v.SetDefault("layovers.min_connection", "1h")
+	v.SetDefault("layovers.long", "6h")
+	v.SetDefault("amadeus_api_key", "")
+	v.SetDefault("amadeus_api_secret", "")
+	v.SetDefault("rapid_google_api_key", "")
+}
+
+// loadConfig decodes and validates a config. Missing credentials are not
+// reported here; see missingCredentials.
+func loadConfig(v *viper.Viper) (Config, []configProblem) {
+	var c Config
+	if err := v.Unmarshal(&c); err != nil {
+		return c, []configProblem{{key: "config", message: err.Error()}}
+	}
+
+	var problems []configProblem
+	if c.Adults < 1 || c.Adults > 9 {
+		problems = append(problems, configProblem{"adults", fmt.Sprintf("must be between 1 and 9, got %d", c.Adults)})
+	}
+	if !currencyCode.MatchString(c.Currency) {
+		problems = append(problems, configProblem{"currency", fmt.Sprintf("must be a three-letter ISO 4217 code like CAD, got %q", c.Currency)})
+	}
+	if !slices.Contains(searchProviders, c.Provider) {
+		problems = append(problems, configProblem{"provider", fmt.Sprintf("must be one of %s, got %q", strings.Join(searchProviders, ", "), c.Provider)})
+	}
+	if _, builtin := styles.Builtin(c.Theme); c.Theme != "" && !builtin && !v.IsSet("themes."+c.Theme) {
+		problems = append(problems, configProblem{"theme", fmt.Sprintf("no built-in theme or themes.%s entry named %q", c.Theme, c.Theme)})
+	}
+	if c.Keymap != "" && c.Keymap != "default" && c.Keymap != "vim" {
+		problems = append(problems, configProblem{"keymap", fmt.Sprintf(`must be "default" or "vim", got %q`, c.Keymap)})
+	}
+	if _, err := c.ExchangeRates.source(); err != nil {
+		problems = append(problems, configProblem{"exchange_rates", err.Error()})
+	}
+	problems = append(problems, c.Luggage.validate("luggage")...)
+	problems = append(problems, validateBagFees(c.BagFees)...)
+	problems = append(problems, c.Layovers.validate()...)
+	problems = append(problems, c.validateProfiles()...)
+	return c, problems
+}
+
+// missingCredentials reports the credentials a provider needs that can't
+// be found, or whose file or helper command fails.
+func missingCredentials(v *viper.Viper, provider string) []configProblem {
+	creds := providerCredentials[provider]
+	var problems []configProblem
+	for _, key := range creds.keys {
+		value, err := lookupSecret(v, key)
+		switch {
+		case err != nil:
+			problems = append(problems, configProblem{key, err.Error()})
+		case value == "":
+			problems = append(problems, unsetCredential(key, creds.signup))
+		}
+	}
+	return problems
+}
+
+// unsetCredentials reports the credentials a provider needs that have no
+// value, file or helper command configured. Unlike missingCredentials it
+// reads no files and runs no helpers, so the TUI can call it from Update;
+// the credentials themselves are resolved when the search runs.
+func unsetCredentials(v *viper.Viper, provider string) []configProblem {
+	creds := providerCredentials[provider]
+	var problems []configProblem
+	for _, key := range creds.keys {
+		if !secretSourceFor(v, key).configured() {
+			problems = append(problems, unsetCredential(key, creds.signup))
+		}
+	}
+	return problems
+}
+
+func unsetCredential(key, signup string) configProblem {
+	return configProblem{key, fmt.Sprintf(
+		"is not set; get one at %s, then set FLYCTL_%s, %s_file or %s_command (or run `flyctl config set %s <value>`)",
+		signup, strings.ToUpper(key), key, key, key,
+	)}
+}
 
+func joinProblems(problems []configProblem) error {
+	errs := make([]error, len(problems))
+	for i, p := range problems {
+		errs[i] = errors.New(p.String())
+	}
+	return errors.Join(errs...)
+}
+
+// setConfigValues writes values under dotted keys into the config file,
+// keeping its comments and layout. The result is validated before it
+// replaces the file, and the running config is reloaded.
+func setConfigValues(values map[string]any) error {
+	path := viper.ConfigFileUsed()
+	raw, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("read %q: %w", path, err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return fmt.Errorf("parse %q: %w", path, err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	for key, value := range values {
+		var node yaml.Node
+		if err := node.Encode(value); err != nil {
+			return fmt.Errorf("set %s: %w", key, err)
 		}
+		if err := setYAMLPath(doc.Content[0], strings.Split(key, "."), &node); err != nil {
+			return fmt.Errorf("set %s: %w", key, err)
+		}
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+
+	if err := checkConfig(out.Bytes()); err != nil {
+		return err
+	}
+	if err := store.WriteFile(path, out.Bytes(), 0o600); err != nil {
+		return err
 	}
+	if err := viper.ReadInConfig(); err != nil {
+		return err
+	}
+	forgetConfig()
 	return nil
 }
+
+// reloadConfig re-reads the config file. New contents that do not parse or
+// validate are not loaded, so the running config stays as it was.
+func reloadConfig() error {
+	raw, err := os.ReadFile(viper.ConfigFileUsed())
+	if err != nil {
+		return err
+	}
+	if err := checkConfig(raw); err != nil {
+		return err
+	}
+	return viper.ReadInConfig()
+}
+
+// checkConfig validates config file contents without loading them.
+func checkConfig(raw []byte) error {
+	candidate := viper.New()
+	candidate.SetConfigType("yaml")
+	setConfigDefaults(candidate)
+	if err := candidate.ReadConfig(bytes.NewReader(raw)); err != nil {
+		return fmt.Errorf("config does not parse: %w", err)
+	}
+	if _, problems := loadConfig(candidate); len(problems) > 0 {
+		return joinProblems(problems)
+	}
+	return nil
+}
+
+// setYAMLPath sets path under a mapping node, creating mappings as needed.
+func setYAMLPath(node *yaml.Node, path []string, value *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("%q is not a section", path[0])
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		if node.Content[i].Value != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			node.Content[i+1] = value
+			return nil
+		}
+		return setYAMLPath(node.Content[i+1], path[1:], value)
+	}
+
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Value: path[0]}
+	if len(path) == 1 {
+		node.Content = append(node.Content, keyNode, value)
+		return nil
+	}
+	child := &yaml.Node{Kind: yaml.MappingNode}
+	node.Content = append(node.Content, keyNode, child)
+	return setYAMLPath(child, path[1:], value)
+}
+
+// parseConfigValue reads a value typed on the command line for key. String
+// settings, including credentials and their _file and _command variants,
+// are kept exactly as typed, so a key like 0123 doesn't turn into a number.
+// Anything else is read as YAML, so `adults 2` sets a number and
+// `keys.global.quit '[ctrl+c, q]'` a list.
+func parseConfigValue(key, s string) any {
+	if t, ok := configFieldType(key); ok {
+		if t.Kind() == reflect.String || t == reflect.TypeFor[time.Duration]() {
+			return s
+		}
+	} else if strings.HasSuffix(key, "_file") || strings.HasSuffix(key, "_command") {
+		return s
+	}
+	var v any
+	if err := yaml.Unmarshal([]byte(s), &v); err != nil || v == nil {
+		return s
+	}
+	return v
+}
+
+// configFieldType is the type of the Config field a dotted key sets,
+// following mapstructure tags into structs and maps. It is false for keys
+// Config doesn't decode, such as keybindings.
+func configFieldType(key string) (reflect.Type, bool) {
+	t := reflect.TypeFor[Config]()
+	for _, part := range strings.Split(key, ".") {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Map:
+			t = t.Elem()
+		case reflect.Struct:
+			field, ok := structField(t, part)
+			if !ok {
+				return nil, false
+			}
+			t = field.Type
+		default:
+			return nil, false
+		}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t, true
+}
+
+func structField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag, _, _ := strings.Cut(f.Tag.Get("mapstructure"), ",")
+		if tag == "" {
+			tag = f.Name
+		}
+		if strings.EqualFold(tag, name) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
+)
+
+const configUsage = `usage: flyctl config <command>
+
+commands:
+  path               print the config file location
+  get <key>          print a value, e.g. currency or keys.global.quit
+  set <key> <value>  change a value; the value is read as YAML
+  edit               open the config in $VISUAL or $EDITOR
+  validate           report problems with the config`
+
+// runConfigCommand runs `flyctl config`. loadErr is the error from loading
+// the config, if any; edit and path still work on a config that does not
+// parse, so it can be fixed.
+func runConfigCommand(args []string, configPath string, loadErr error) error {
+	if len(args) == 0 {
+		return errors.New(configUsage)
+	}
+	path, err := configFilePath(configPath)
+	if err != nil {
+		return err
+	}
+
+	switch cmd, args := args[0], args[1:]; {
+	case cmd == "path" && len(args) == 0:
+		fmt.Println(path)
+		return nil
+	case cmd == "edit" && len(args) == 0:
+		if err := editFile(path); err != nil {
+			return err
+		}
+		if err := viper.ReadInConfig(); err != nil {
+			return fmt.Errorf("config no longer parses: %w", err)
+		}
+		return validateConfig()
+	case loadErr != nil:
+		return fmt.Errorf("%w (fix it with `flyctl config edit`)", loadErr)
+	case cmd == "validate" && len(args) == 0:
+		if err := validateConfig(); err != nil {
+			return err
+		}
+		fmt.Println(path, "is valid")
+		return nil
+	case cmd == "get" && len(args) == 1:
+		if !viper.IsSet(args[0]) {
+			return fmt.Errorf("%s is not set", args[0])
+		}
+		value := viper.Get(args[0])
+		if s, ok := value.(string); ok {
+			fmt.Println(s)
+			return nil
+		}
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		return enc.Encode(value)
+	case cmd == "set" && len(args) == 2:
+		return setConfigValues(map[string]any{args[0]: parseConfigValue(args[0], args[1])})
+	default:
+		return errors.New(configUsage)
+	}
+}
+
+// validateConfig reports every problem with the loaded config, including
+// missing credentials for the configured provider.
+func validateConfig() error {
+	cfg, problems := loadConfig(viper.GetViper())
+	problems = append(problems, missingCredentials(viper.GetViper(), cfg.Provider)...)
+	return joinProblems(problems)
+}
+
+func editFile(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	// $EDITOR may carry flags, e.g. "code --wait"
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run %s: %w", editor, err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fsnotify/fsnotify"
+	"github.com/justinm35/flyctl/styles"
+)
+
+// configSettleDelay lets a burst of events from one save (editors often
+// truncate, write and rename) settle before the file is read.
+const configSettleDelay = 150 * time.Millisecond
+
+// configChangedMsg reports that config.yaml has new contents on disk.
+type configChangedMsg struct{}
+
+// watchConfig sends a configChangedMsg whenever the contents of path change.
+// The directory is watched rather than the file, so saves that replace the
+// file by renaming are seen too.
+func watchConfig(path string, send func(tea.Msg)) (stop func(), err error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	path = filepath.Clean(path)
+	last, _ := os.ReadFile(path)
+	changed := make(chan struct{}, 1)
+
+	go func() {
+		var settle *time.Timer
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != path || event.Op == fsnotify.Chmod {
+					continue
+				}
+				if settle != nil {
+					settle.Stop()
+				}
+				settle = time.AfterFunc(configSettleDelay, func() {
+					select {
+					case changed <- struct{}{}:
+					default:
+					}
+				})
+			case <-changed:
+				raw, err := os.ReadFile(path)
+				if err != nil || bytes.Equal(raw, last) {
+					continue
+				}
+				last = raw
+				send(configChangedMsg{})
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				slog.Warn("watch config", "err", err)
+			}
+		}
+	}()
+	return func() { watcher.Close() }, nil
+}
+
+// applyConfigChange reloads the config and applies what can change while
+// running: theme, keybindings and layover rules here; credentials, currency
+// and profiles are read at search time.
+func (m *Model) applyConfigChange() tea.Cmd {
+	if err := reloadConfig(); err != nil {
+		slog.Warn("config not reloaded", "err", err)
+		return m.setStatus("config not reloaded, keeping the last good one: " + strings.ReplaceAll(err.Error(), "\n", "; "))
+	}
+	slog.Info("config reloaded")
+	forgetConfig()
+	forgetSecrets()
+
+	theme, err := loadTheme()
+	if err != nil {
+		slog.Error("load theme", "err", err)
+	}
+	styles.SetActive(theme)
+	applyLocale()
+	applyLayoverRules()
+	m.screenResults.refreshConnections()
+	for i := range m.tabs {
+		m.tabs[i].results.refreshConnections()
+	}
+	m.screenFlightDetails.refresh()
+	m.applyTheme()
+	m.keys = loadKeyMap()
+	m.applyKeys()
+	return m.setStatus("config reloaded")
+}
@@ -1,15 +0,0 @@
-package main
-
-import (
-	"log"
-	"os"
-)
-
-func init() {
-	f, err := os.OpenFile("flyctl-debug.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-	if err != nil {
-		panic(err)
-	}
-	log.SetOutput(f)
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
-}
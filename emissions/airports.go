@@ -0,0 +1,57 @@
+package emissions
+
+// airports are the coordinates of common airports by IATA code, in
+// degrees.
+var airports = map[string]struct{ lat, lon float64 }{
+	// Canada
+	"YYZ": {43.677, -79.631}, "YTZ": {43.628, -79.396}, "YUL": {45.470, -73.741}, "YVR": {49.195, -123.184},
+	"YYC": {51.131, -114.011}, "YEG": {53.310, -113.580}, "YOW": {45.323, -75.669}, "YWG": {49.910, -97.240},
+	"YHZ": {44.881, -63.509}, "YQB": {46.791, -71.393}, "YXE": {52.171, -106.700}, "YQR": {50.432, -104.666},
+	"YYJ": {48.647, -123.426}, "YKF": {43.457, -80.386}, "YHM": {43.174, -79.935}, "YYT": {47.619, -52.752},
+	"YLW": {49.956, -119.378}, "YXU": {43.036, -81.154}, "YQM": {46.112, -64.679}, "YXY": {60.710, -135.067},
+	"YZF": {62.463, -114.440},
+	// United States
+	"ATL": {33.637, -84.428}, "BOS": {42.366, -71.010}, "BWI": {39.177, -76.668}, "CLT": {35.214, -80.943},
+	"DCA": {38.852, -77.038}, "DEN": {39.862, -104.673}, "DFW": {32.897, -97.038}, "DTW": {42.212, -83.353},
+	"EWR": {40.692, -74.169}, "FLL": {26.073, -80.153}, "HNL": {21.318, -157.922}, "IAD": {38.944, -77.456},
+	"IAH": {29.984, -95.341}, "JFK": {40.640, -73.779}, "LAS": {36.084, -115.154}, "LAX": {33.943, -118.408},
+	"LGA": {40.777, -73.873}, "MCO": {28.429, -81.309}, "MDW": {41.786, -87.752}, "MIA": {25.793, -80.291},
+	"MSP": {44.882, -93.222}, "ORD": {41.979, -87.905}, "PDX": {45.589, -122.597}, "PHL": {39.872, -75.241},
+	"PHX": {33.434, -112.012}, "SAN": {32.734, -117.190}, "SEA": {47.450, -122.309}, "SFO": {37.619, -122.375},
+	"SLC": {40.788, -111.978}, "TPA": {27.976, -82.533}, "AUS": {30.194, -97.670}, "BNA": {36.124, -86.678},
+	"MSY": {29.993, -90.258}, "RDU": {35.878, -78.787}, "SJC": {37.363, -121.929}, "OAK": {37.721, -122.221},
+	"ANC": {61.174, -149.996}, "PIT": {40.492, -80.233}, "CLE": {41.412, -81.850}, "STL": {38.749, -90.370},
+	"BUF": {42.940, -78.732}, "SJU": {18.439, -66.002},
+	// Mexico, Caribbean, Central and South America
+	"MEX": {19.436, -99.072}, "CUN": {21.037, -86.877}, "GDL": {20.522, -103.311}, "PVR": {20.680, -105.254},
+	"SJD": {23.152, -109.721}, "MBJ": {18.504, -77.913}, "KIN": {17.936, -76.788}, "NAS": {25.039, -77.466},
+	"PUJ": {18.567, -68.363}, "SDQ": {18.430, -69.669}, "HAV": {22.989, -82.409}, "VRA": {23.034, -81.435},
+	"PTY": {9.071, -79.383}, "SJO": {9.994, -84.209}, "LIR": {10.593, -85.544}, "BOG": {4.702, -74.147},
+	"LIM": {-12.022, -77.114}, "SCL": {-33.393, -70.786}, "EZE": {-34.822, -58.536}, "GRU": {-23.432, -46.470},
+	"GIG": {-22.810, -43.251}, "UIO": {-0.129, -78.358},
+	// Europe
+	"LHR": {51.470, -0.454}, "LGW": {51.148, -0.190}, "STN": {51.885, 0.235}, "MAN": {53.354, -2.275},
+	"EDI": {55.950, -3.373}, "DUB": {53.421, -6.270}, "CDG": {49.010, 2.548}, "ORY": {48.723, 2.379},
+	"NCE": {43.658, 7.216}, "LYS": {45.726, 5.091}, "AMS": {52.311, 4.768}, "BRU": {50.901, 4.484},
+	"FRA": {50.033, 8.571}, "MUC": {48.354, 11.786}, "BER": {52.362, 13.501}, "DUS": {51.289, 6.767},
+	"HAM": {53.630, 9.988}, "ZRH": {47.465, 8.549}, "GVA": {46.238, 6.109}, "VIE": {48.110, 16.570},
+	"MAD": {40.472, -3.561}, "BCN": {41.297, 2.078}, "PMI": {39.552, 2.739}, "AGP": {36.675, -4.499},
+	"LIS": {38.774, -9.134}, "OPO": {41.248, -8.681}, "FCO": {41.800, 12.239}, "MXP": {45.630, 8.723},
+	"VCE": {45.505, 12.352}, "NAP": {40.886, 14.291}, "ATH": {37.936, 23.947}, "IST": {41.262, 28.742},
+	"SAW": {40.898, 29.309}, "CPH": {55.618, 12.656}, "ARN": {59.650, 17.919}, "OSL": {60.194, 11.100},
+	"HEL": {60.317, 24.963}, "KEF": {63.985, -22.606}, "WAW": {52.166, 20.967}, "PRG": {50.101, 14.260},
+	"BUD": {47.439, 19.262}, "OTP": {44.571, 26.085},
+	// Middle East and Africa
+	"DXB": {25.253, 55.364}, "AUH": {24.433, 54.651}, "DOH": {25.273, 51.608}, "TLV": {32.011, 34.887},
+	"AMM": {31.723, 35.993}, "RUH": {24.958, 46.699}, "JED": {21.680, 39.157}, "CAI": {30.122, 31.406},
+	"CMN": {33.367, -7.590}, "RAK": {31.607, -8.036}, "ADD": {8.978, 38.799}, "NBO": {-1.319, 36.928},
+	"JNB": {-26.139, 28.246}, "CPT": {-33.965, 18.602}, "LOS": {6.577, 3.321}, "ACC": {5.605, -0.167},
+	// Asia and Oceania
+	"HND": {35.552, 139.780}, "NRT": {35.765, 140.386}, "KIX": {34.427, 135.244}, "ICN": {37.460, 126.441},
+	"PEK": {40.080, 116.585}, "PKX": {39.510, 116.411}, "PVG": {31.143, 121.805}, "CAN": {23.392, 113.299},
+	"HKG": {22.308, 113.918}, "TPE": {25.078, 121.233}, "MNL": {14.509, 121.020}, "SIN": {1.359, 103.989},
+	"KUL": {2.746, 101.710}, "BKK": {13.690, 100.750}, "SGN": {10.819, 106.652}, "HAN": {21.221, 105.807},
+	"CGK": {-6.126, 106.656}, "DPS": {-8.748, 115.167}, "DEL": {28.557, 77.100}, "BOM": {19.089, 72.868},
+	"BLR": {13.199, 77.706}, "MAA": {12.990, 80.169}, "SYD": {-33.946, 151.177}, "MEL": {-37.669, 144.841},
+	"BNE": {-27.384, 153.117}, "PER": {-31.940, 115.967}, "AKL": {-37.008, 174.792}, "NAN": {-17.755, 177.443},
+}
@@ -0,0 +1,129 @@
+// Package emissions estimates the CO2 of flights that providers don't
+// report emissions for, from great-circle distance and aircraft type.
+package emissions
+
+import (
+	"math"
+	"strings"
+
+	"github.com/justinm35/flyctl/types"
+)
+
+const (
+	earthRadiusKm = 6371.0
+	// routingFactor accounts for flights not following the great circle.
+	routingFactor = 1.08
+	// cycleGrams is the taxi, takeoff and landing share of one passenger.
+	cycleGrams = 15_000
+)
+
+// aircraftClass is a family of aircraft with a similar burn per seat.
+type aircraftClass struct {
+	name          string
+	gramsPerKm    float64 // per economy passenger, at typical load
+	codes, models []string
+}
+
+// classes are matched in order, so more specific ones come first. codes are
+// IATA type codes as Amadeus reports them; models are substrings of the
+// names other providers give.
+var classes = []aircraftClass{
+	{name: "new widebody", gramsPerKm: 60, codes: []string{"787", "788", "789", "78J", "78X", "359", "351", "35K", "339"}, models: []string{"787", "a350", "a330-900", "a330neo"}},
+	{name: "quad", gramsPerKm: 95, codes: []string{"744", "74H", "388", "380", "346", "343"}, models: []string{"747", "a380", "a340"}},
+	{name: "widebody", gramsPerKm: 70, codes: []string{"77W", "773", "772", "77L", "333", "332", "330", "763", "764", "767"}, models: []string{"777", "a330", "767"}},
+	{name: "new narrowbody", gramsPerKm: 65, codes: []string{"32N", "32Q", "20N", "21N", "7M7", "7M8", "7M9", "7MJ", "221", "223", "BCS"}, models: []string{"neo", "max", "a220", "cs100", "cs300"}},
+	{name: "turboprop", gramsPerKm: 85, codes: []string{"AT4", "AT7", "ATR", "DH4", "DH8", "DHT", "SF3"}, models: []string{"atr", "dash", "q400", "de havilland", "saab 340", "twin otter"}},
+	{name: "regional jet", gramsPerKm: 105, codes: []string{"CR7", "CR9", "CRJ", "CRK", "E70", "E75", "E7W", "E90", "E95", "ER4"}, models: []string{"crj", "canadair", "embraer", "erj"}},
+	{name: "narrowbody", gramsPerKm: 75, codes: []string{"319", "320", "321", "32A", "32B", "73H", "738", "739", "73G", "737", "752", "753", "757"}, models: []string{"a319", "a320", "a321", "737", "757"}},
+}
+
+// typical is the class assumed for an unknown aircraft.
+var typical = aircraftClass{name: "typical", gramsPerKm: 75}
+
+// cabinFactors scale emissions by the floor space a seat takes up.
+var cabinFactors = map[string]float64{
+	"economy":         1,
+	"premium_economy": 1.5,
+	"business":        2.9,
+	"first":           4,
+}
+
+func classOf(aircraft string) aircraftClass {
+	code := strings.ToUpper(strings.TrimSpace(aircraft))
+	model := strings.ToLower(aircraft)
+	for _, c := range classes {
+		for _, s := range c.codes {
+			if code == s {
+				return c
+			}
+		}
+		for _, s := range c.models {
+			if strings.Contains(model, s) {
+				return c
+			}
+		}
+	}
+	return typical
+}
+
+// Distance is the great-circle distance between two airports in
+// kilometres, and false when either is not in the table.
+func Distance(from, to string) (float64, bool) {
+	a, okA := airports[strings.ToUpper(from)]
+	b, okB := airports[strings.ToUpper(to)]
+	if !okA || !okB {
+		return 0, false
+	}
+	lat1, lat2 := a.lat*math.Pi/180, b.lat*math.Pi/180
+	dLat, dLon := lat2-lat1, (b.lon-a.lon)*math.Pi/180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h)), true
+}
+
+func flightGrams(km float64, class aircraftClass, cabin string) float64 {
+	factor, ok := cabinFactors[strings.ToLower(cabin)]
+	if !ok {
+		factor = 1
+	}
+	return (km*routingFactor*class.gramsPerKm + cycleGrams) * factor
+}
+
+// Estimate is the CO2 of one traveler flying the segments in cabin, and a
+// typical figure for the trip: the same journey nonstop on a typical
+// aircraft. It is false when an airport is not in the table.
+func Estimate(segs []types.Segment, cabin string) (types.Emissions, bool) {
+	if len(segs) == 0 {
+		return types.Emissions{}, false
+	}
+	var grams float64
+	for _, s := range segs {
+		km, ok := Distance(s.From, s.To)
+		if !ok {
+			return types.Emissions{}, false
+		}
+		segCabin := cabin
+		if s.Cabin != "" {
+			segCabin = s.Cabin
+		}
+		grams += flightGrams(km, classOf(s.Aircraft), segCabin)
+	}
+
+	// a round trip turns around at the stop farthest from the origin
+	origin, turnaround, legs := segs[0].From, segs[len(segs)-1].To, 1
+	if turnaround == origin {
+		legs = 2
+		far := 0.0
+		for _, s := range segs {
+			if km, _ := Distance(origin, s.To); km > far {
+				far, turnaround = km, s.To
+			}
+		}
+	}
+	direct, _ := Distance(origin, turnaround)
+
+	return types.Emissions{
+		Grams:        int(math.Round(grams)),
+		TypicalGrams: int(math.Round(flightGrams(direct, typical, cabin) * float64(legs))),
+		Estimated:    true,
+	}, true
+}
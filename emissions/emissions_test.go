@@ -0,0 +1,182 @@
+package emissions
+
+import (
+	"math"
+	"testing"
+
+	"github.com/justinm35/flyctl/types"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		from, to string
+		km       float64 // published great-circle distance
+	}{
+		{"YYZ", "LHR", 5705},
+		{"JFK", "LAX", 3983},
+		{"yvr", "yyz", 3345},
+		{"SYD", "LHR", 16993},
+		{"CDG", "CDG", 0},
+	}
+	for _, tt := range tests {
+		got, ok := Distance(tt.from, tt.to)
+		if !ok {
+			t.Errorf("Distance(%s, %s) not found", tt.from, tt.to)
+			continue
+		}
+		if math.Abs(got-tt.km) > tt.km*0.01+1 {
+			t.Errorf("Distance(%s, %s) = %.0f km, want about %.0f", tt.from, tt.to, got, tt.km)
+		}
+	}
+	if _, ok := Distance("YYZ", "XXX"); ok {
+		t.Error("Distance to an unknown airport was found")
+	}
+}
+
+func TestClassOf(t *testing.T) {
+	tests := []struct {
+		aircraft string
+		want     string
+	}{
+		{"789", "new widebody"},
+		{"Boeing 787-9", "new widebody"},
+		{"Airbus A330-900neo", "new widebody"},
+		{"Airbus A330", "widebody"},
+		{" 77w ", "widebody"},
+		{"Airbus A380", "quad"},
+		{"32N", "new narrowbody"},
+		{"Airbus A321neo", "new narrowbody"},
+		{"Boeing 737 MAX 8", "new narrowbody"},
+		{"Boeing 737", "narrowbody"},
+		{"De Havilland Canada Dash 8-400", "turboprop"},
+		{"E75", "regional jet"},
+		{"Embraer 175", "regional jet"},
+		{"", "typical"},
+		{"Concorde", "typical"},
+	}
+	for _, tt := range tests {
+		if got := classOf(tt.aircraft).name; got != tt.want {
+			t.Errorf("classOf(%q) = %s, want %s", tt.aircraft, got, tt.want)
+		}
+	}
+}
+
+func seg(from, to, aircraft, cabin string) types.Segment {
+	return types.Segment{From: from, To: to, Aircraft: aircraft, Cabin: cabin}
+}
+
+func TestEstimate(t *testing.T) {
+	yyzLHR, _ := Distance("YYZ", "LHR")
+	yyzCPH, _ := Distance("YYZ", "CPH")
+	grams := func(km, perKm, cabin float64) int {
+		return int(math.Round((km*routingFactor*perKm + cycleGrams) * cabin))
+	}
+
+	tests := []struct {
+		name    string
+		segs    []types.Segment
+		cabin   string
+		grams   int
+		typical int
+	}{
+		{
+			name:    "nonstop on an unknown aircraft is typical",
+			segs:    []types.Segment{seg("YYZ", "LHR", "", "")},
+			cabin:   "economy",
+			grams:   grams(yyzLHR, 75, 1),
+			typical: grams(yyzLHR, 75, 1),
+		},
+		{
+			name:    "nonstop on a new widebody",
+			segs:    []types.Segment{seg("YYZ", "LHR", "789", "")},
+			cabin:   "economy",
+			grams:   grams(yyzLHR, 60, 1),
+			typical: grams(yyzLHR, 75, 1),
+		},
+		{
+			name:    "business class scales both figures",
+			segs:    []types.Segment{seg("YYZ", "LHR", "Boeing 777", "")},
+			cabin:   "business",
+			grams:   grams(yyzLHR, 70, 2.9),
+			typical: grams(yyzLHR, 75, 2.9),
+		},
+		{
+			name:    "a segment's own cabin wins over the search cabin",
+			segs:    []types.Segment{seg("YYZ", "LHR", "", "first")},
+			cabin:   "economy",
+			grams:   grams(yyzLHR, 75, 4),
+			typical: grams(yyzLHR, 75, 1),
+		},
+		{
+			name:    "an unknown cabin counts as economy",
+			segs:    []types.Segment{seg("YYZ", "LHR", "", "")},
+			cabin:   "steerage",
+			grams:   grams(yyzLHR, 75, 1),
+			typical: grams(yyzLHR, 75, 1),
+		},
+		{
+			name:    "a round trip is typical against twice the way out",
+			segs:    []types.Segment{seg("YYZ", "LHR", "", ""), seg("LHR", "YYZ", "", "")},
+			cabin:   "economy",
+			grams:   2 * grams(yyzLHR, 75, 1),
+			typical: grams(yyzLHR, 75, 2),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Estimate(tt.segs, tt.cabin)
+			if !ok {
+				t.Fatal("Estimate failed")
+			}
+			if !got.Estimated {
+				t.Error("Estimated not set")
+			}
+			// the sum of rounded parts may be a gram off the rounded sum
+			if abs(got.Grams-tt.grams) > 1 || abs(got.TypicalGrams-tt.typical) > 1 {
+				t.Errorf("Estimate = %d g, typical %d g, want %d g, typical %d g", got.Grams, got.TypicalGrams, tt.grams, tt.typical)
+			}
+		})
+	}
+
+	t.Run("a connection emits more than the typical nonstop", func(t *testing.T) {
+		got, ok := Estimate([]types.Segment{seg("YYZ", "FRA", "", ""), seg("FRA", "CPH", "", "")}, "economy")
+		if !ok {
+			t.Fatal("Estimate failed")
+		}
+		if want := grams(yyzCPH, 75, 1); got.TypicalGrams != want {
+			t.Errorf("typical = %d g, want the nonstop YYZ-CPH %d g", got.TypicalGrams, want)
+		}
+		if got.Grams <= got.TypicalGrams {
+			t.Errorf("Estimate = %d g, want more than typical %d g", got.Grams, got.TypicalGrams)
+		}
+	})
+
+	t.Run("a multi-stop round trip turns around at the farthest stop", func(t *testing.T) {
+		got, ok := Estimate([]types.Segment{
+			seg("YYZ", "LHR", "", ""), seg("LHR", "CPH", "", ""),
+			seg("CPH", "LHR", "", ""), seg("LHR", "YYZ", "", ""),
+		}, "economy")
+		if !ok {
+			t.Fatal("Estimate failed")
+		}
+		if want := grams(yyzCPH, 75, 2); got.TypicalGrams != want {
+			t.Errorf("typical = %d g, want twice YYZ-CPH %d g", got.TypicalGrams, want)
+		}
+	})
+
+	for name, segs := range map[string][]types.Segment{
+		"no segments":     nil,
+		"unknown airport": {seg("YYZ", "LHR", "", ""), seg("LHR", "XXX", "", "")},
+	} {
+		if _, ok := Estimate(segs, "economy"); ok {
+			t.Errorf("Estimate with %s succeeded", name)
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
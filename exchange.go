@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/justinm35/flyctl/money"
+	"github.com/justinm35/flyctl/providers/transport"
+	"github.com/justinm35/flyctl/store"
+	"github.com/justinm35/flyctl/types"
+)
+
+const (
+	defaultRatesURL    = "https://api.frankfurter.app/latest"
+	defaultRatesMaxAge = 12 * time.Hour
+)
+
+// ExchangeRates configures where conversion rates come from:
+//
+//	exchange_rates:
+//	  source: http # http (default), file or table
+//	  url: https://api.frankfurter.app/latest
+//	  max_age: 12h # how long fetched rates are reused
+//	  file: ~/rates.json # for source: file, same shape as the http response
+//	  table: # for source: table
+//	    base: EUR
+//	    date: 2026-10-01
+//	    rates: {USD: 1.0842, CAD: 1.4711}
+type ExchangeRates struct {
+	Source string        `mapstructure:"source"`
+	URL    string        `mapstructure:"url"`
+	MaxAge time.Duration `mapstructure:"max_age"`
+	File   string        `mapstructure:"file"`
+	Table  struct {
+		Base  string            `mapstructure:"base"`
+		Date  string            `mapstructure:"date"`
+		Rates map[string]string `mapstructure:"rates"`
+	} `mapstructure:"table"`
+}
+
+// rateSource loads a table of exchange rates.
+type rateSource interface {
+	rates(ctx context.Context) (money.Rates, error)
+	name() string
+}
+
+// fileRates reads rates from a JSON file kept up to date by something else.
+type fileRates struct{ path string }
+
+func (s fileRates) name() string { return "file " + s.path }
+
+func (s fileRates) rates(context.Context) (money.Rates, error) {
+	path, err := expandHome(s.path)
+	if err != nil {
+		return money.Rates{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return money.Rates{}, err
+	}
+	return money.ParseRates(data)
+}
+
+// httpRates fetches rates and keeps the response in the cache directory, so
+// they are fetched at most once per maxAge. Stale rates are used when the
+// endpoint can't be reached.
+type httpRates struct {
+	url       string
+	maxAge    time.Duration
+	cachePath string
+}
+
+func (s httpRates) name() string { return s.url }
+
+func (s httpRates) rates(ctx context.Context) (money.Rates, error) {
+	cached, cacheErr := os.ReadFile(s.cachePath)
+	if info, err := os.Stat(s.cachePath); cacheErr == nil && err == nil && time.Since(info.ModTime()) < s.maxAge {
+		if r, err := money.ParseRates(cached); err == nil {
+			return r, nil
+		}
+	}
+
+	r, err := s.fetch(ctx)
+	if err != nil {
+		if stale, staleErr := money.ParseRates(cached); cacheErr == nil && staleErr == nil {
+			slog.Warn("fetch exchange rates, using cached ones", "url", s.url, "date", stale.Date.Format(time.DateOnly), "err", err)
+			return stale, nil
+		}
+		return money.Rates{}, err
+	}
+	return r, nil
+}
+
+func (s httpRates) fetch(ctx context.Context) (money.Rates, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return money.Rates{}, err
+	}
+	resp, err := transport.NewClient("exchange-rates").Do(req)
+	if err != nil {
+		return money.Rates{}, fmt.Errorf("fetch exchange rates: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return money.Rates{}, fmt.Errorf("fetch exchange rates: %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return money.Rates{}, fmt.Errorf("fetch exchange rates: %w", err)
+	}
+	r, err := money.ParseRates(data)
+	if err != nil {
+		return money.Rates{}, err
+	}
+	if err := store.WriteFile(s.cachePath, data, 0o600); err != nil {
+		slog.Warn("cache exchange rates", "path", s.cachePath, "err", err)
+	}
+	return r, nil
+}
+
+// tableRates are rates typed into the config.
+type tableRates struct{ table money.Rates }
+
+func (s tableRates) name() string { return "config" }
+
+func (s tableRates) rates(context.Context) (money.Rates, error) { return s.table, nil }
+
+// source builds the rate source the config describes.
+func (cfg ExchangeRates) source() (rateSource, error) {
+	switch cfg.Source {
+	case "", "http":
+		dir, err := cacheDir()
+		if err != nil {
+			return nil, err
+		}
+		s := httpRates{url: cfg.URL, maxAge: cfg.MaxAge, cachePath: filepath.Join(dir, "exchange-rates.json")}
+		if s.url == "" {
+			s.url = defaultRatesURL
+		}
+		if s.maxAge <= 0 {
+			s.maxAge = defaultRatesMaxAge
+		}
+		return s, nil
+	case "file":
+		if cfg.File == "" {
+			return nil, errors.New("file is required with source: file")
+		}
+		return fileRates{path: cfg.File}, nil
+	case "table":
+		date, err := time.Parse(time.DateOnly, cfg.Table.Date)
+		if err != nil {
+			return nil, fmt.Errorf("table.date: %w", err)
+		}
+		r, err := money.NewRates(cfg.Table.Base, date, cfg.Table.Rates)
+		if err != nil {
+			return nil, fmt.Errorf("table: %w", err)
+		}
+		return tableRates{r}, nil
+	default:
+		return nil, fmt.Errorf(`source must be "http", "file" or "table", got %q`, cfg.Source)
+	}
+}
+
+// lazyRates loads the configured rates the first time they are needed, so
+// a search with every price already in the display currency never touches
+// the network.
+type lazyRates struct {
+	ctx    context.Context
+	cfg    ExchangeRates
+	loaded bool
+	rates  money.Rates
+	source rateSource
+	err    error
+}
+
+func newLazyRates(ctx context.Context, cfg ExchangeRates) *lazyRates {
+	return &lazyRates{ctx: ctx, cfg: cfg}
+}
+
+func (l *lazyRates) get() (money.Rates, error) {
+	if !l.loaded {
+		l.loaded = true
+		if l.source, l.err = l.cfg.source(); l.err == nil {
+			l.rates, l.err = l.source.rates(l.ctx)
+		}
+		if l.err != nil {
+			slog.Error("load exchange rates", "err", l.err)
+		}
+	}
+	return l.rates, l.err
+}
+
+// convertOffers prices every offer in currency. Offers already quoted in
+// it are left alone; offers that can't be converted keep their quoted price
+// and the failure is logged, so a missing rate never hides results.
+func convertOffers(offers []types.FlightOffer, currency string, rates *lazyRates) []types.FlightOffer {
+	for i, o := range offers {
+		offers[i].Converted = nil
+		if o.TotalPrice.Currency == currency || o.TotalPrice.Currency == "" {
+			continue
+		}
+		r, err := rates.get()
+		if err != nil {
+			continue
+		}
+
+		price, rate, err := r.Convert(o.TotalPrice, currency)
+		if err != nil {
+			slog.Warn("convert price", "offer", o.OfferID, "err", err)
+			continue
+		}
+		offers[i].Converted = &types.Conversion{
+			Price:    price,
+			Rate:     money.FormatRate(rate),
+			RateDate: r.Date,
+			Source:   rates.source.name(),
+		}
+	}
+	return offers
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/justinm35/flyctl/money"
+	"github.com/justinm35/flyctl/types"
+)
+
+// exportResultsCSV writes the offers to a timestamped CSV file in the
+// working directory and returns its path.
+func exportResultsCSV(offers []types.FlightOffer, starred map[string]bool) (string, error) {
+	if len(offers) == 0 {
+		return "", fmt.Errorf("no results to export")
+	}
+
+	path := fmt.Sprintf("flyctl-results-%s.csv", time.Now().Format("20060102-150405"))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("create %q: %w", path, err)
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"starred", "provider", "offer_id", "route", "depart_at", "arrive_at", "stops", "price", "price_minor", "currency", "display_price", "display_currency", "carriers"})
+	for _, o := range offers {
+		if len(o.Segments) == 0 {
+			continue
+		}
+		route := []string{o.Segments[0].From}
+		var carriers []string
+		for _, s := range o.Segments {
+			route = append(route, s.To)
+			if s.Carrier != "" && !slices.Contains(carriers, s.Carrier) {
+				carriers = append(carriers, s.Carrier)
+			}
+		}
+		w.Write([]string{
+			strconv.FormatBool(starred[o.OfferID]),
+			o.Provider,
+			o.OfferID,
+			strings.Join(route, "-"),
+			o.Segments[0].DepartAt.Format(time.RFC3339),
+			o.Segments[len(o.Segments)-1].ArriveAt.Format(time.RFC3339),
+			strconv.Itoa(len(o.Segments) - 1),
+			money.Decimal(o.TotalPrice),
+			strconv.FormatInt(o.TotalPrice.Amount, 10),
+			o.TotalPrice.Currency,
+			money.Decimal(o.Price()),
+			o.Price().Currency,
+			strings.Join(carriers, " "),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", fmt.Errorf("write %q: %w", path, err)
+	}
+	return path, nil
+}
@@ -5,12 +5,14 @@ import (
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/justinm35/flyctl/styles"
 	"github.com/justinm35/flyctl/types"
+	"github.com/justinm35/flyctl/utils"
 )
 
 type FlightDetailsState struct {
@@ -20,73 +22,63 @@ type FlightDetailsState struct {
 }
 
 func (flightDetailsState *FlightDetailsState) initFlightDetails(selectedOffer types.FlightOffer) {
-	vp := viewport.New(50, 50)
+	flightDetailsState.offer = selectedOffer
+	flightDetailsState.refresh()
+	flightDetailsState.viewport.GotoTop()
+}
 
-	flightDetailsState.viewport = vp
+// resize fits the viewport to the inner size of the details pane and
+// re-renders the content for the new width.
+func (flightDetailsState *FlightDetailsState) resize(width, height int) {
+	flightDetailsState.viewport.Width = width
+	flightDetailsState.viewport.Height = height
+	flightDetailsState.refresh()
+}
 
-	flightDetailsState.offer = selectedOffer
+func (flightDetailsState *FlightDetailsState) refresh() {
+	flightDetailsState.viewport.SetContent(lipGlossRender(flightDetailsState.offer, flightDetailsState.viewport.Width))
 }
 
-func newFlightDetailsState() FlightDetailsState {
-	return FlightDetailsState{}
+func newFlightDetailsState(keys detailsKeyMap) FlightDetailsState {
+	vp := viewport.New(50, 20)
+	vp.KeyMap = keys.viewportKeyMap()
+	return FlightDetailsState{viewport: vp}
 }
 
 func updateFlightDetails(m Model, msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
-	// case tea.WindowSizeMsg:
-	// 	headerH := 1
-	// 	footerH := 1
-	// 	verticalPadding := 0
-	// 	m.screenFlightDetails.viewport.Width = msg.Width
-	// 	m.screenFlightDetails.viewport.Height = msg.Height - headerH - footerH - verticalPadding
-	// 	if m.screenFlightDetails.viewport.Height < 1 {
-	// 		m.screenFlightDetails.viewport.Height = 1
-	// 	}
-	// 	return m, nil
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "enter":
+		switch {
+		case msg.Type == tea.KeyEnter:
 			return m, nil
-		case "b":
+		case key.Matches(msg, m.keys.Details.Back):
 			m.screen = screenResults
 			return m, nil
 		}
 	}
 
+	m.screenFlightDetails.viewport, cmd = m.screenFlightDetails.viewport.Update(msg)
 	return m, cmd
 }
 
 func viewFlightDetails(m Model) string {
-	const width = 78
-	const glamourGutter = 2
-	vp := m.screenFlightDetails.viewport
-	halfHeight := (m.height / 2) - 2
-
-	vp.Height = halfHeight
-	vp.Width = m.width / 2
-
-	lipGlossRender := lipGlossRender(m.screenFlightDetails.offer, m.width)
-
-	vp.SetContent(lipGlossRender)
-
-	return vp.View()
+	return m.screenFlightDetails.viewport.View()
 }
 
 func lipGlossRender(offer types.FlightOffer, width int) string {
 
 	const dateLayout = "Mon, 02 Jan 2006"
-	const timeLayout = "15:04 MST"
 
-	header := lipgloss.NewStyle().Foreground(styles.NeonPurple).Bold(true).Width(30).Render("[Selected Flight Details] \n")
-	noResults := lipgloss.NewStyle().Foreground(styles.MutedGray).Align(lipgloss.Center).MarginTop(6).Width(width / 2).Render("Search & Select a flight...")
+	header := styles.Active().Title().Width(30).Render("[Selected Flight Details] \n")
+	noResults := lipgloss.NewStyle().Foreground(styles.Active().Muted).Align(lipgloss.Center).MarginTop(2).Width(width).Render("Search & Select a flight...")
 	if offer.Segments == nil {
 		return fmt.Sprintf("%s \n\n\n %s", header, noResults)
 	}
 
 	departingFlihtLine := fmt.Sprintf("Departure Date: %s", offer.Segments[0].DepartAt.UTC().Format(dateLayout))
-	totalPriceLine := fmt.Sprintf("Price (%s): %d", offer.TotalPrice.Currency, offer.TotalPrice.Amount)
+	totalPriceLine := "Price: " + priceLine(offer)
 
 	departingFlight := lipgloss.NewStyle().Render(departingFlihtLine)
 	totalPrice := lipgloss.NewStyle().Render(totalPriceLine)
@@ -100,56 +92,135 @@ func lipGlossRender(offer types.FlightOffer, width int) string {
 		totalPrice,
 	)
 
-	renderer, err := glamour.NewTermRenderer()
+	renderer, err := glamour.NewTermRenderer(glamour.WithWordWrap(max(width-4, 20)))
 	if err != nil {
 		return ""
 	}
 	var b strings.Builder
 
+	b.WriteString(priceBreakdown(offer))
+	b.WriteString(emissionsLine(offer))
 	b.WriteString("```text\n")
+	b.WriteString(segmentTimeline(offer, 64))
+	b.WriteString("```\n\n")
+
+	routeDetails, err := renderer.Render(b.String())
+	if err != nil {
+		return ""
+	}
+
+	fillView := lipgloss.JoinVertical(lipgloss.Left, header, departureAndPrice, routeDetails)
+	return lipgloss.NewStyle().Render(fillView)
+}
+
+// segmentTimeline draws the segments of an offer as a vertical timeline, with
+// a ruled-off layover between consecutive segments.
+func segmentTimeline(offer types.FlightOffer, ruleWidth int) string {
+	const timeLayout = "15:04 MST"
+
+	rule := strings.Repeat("─", ruleWidth)
+	warnings := layoverRules.offerWarnings(offer)
+	var b strings.Builder
 	for i, s := range offer.Segments {
 
 		if i != 0 {
 			fmt.Fprintf(&b, "│\n")
 		}
 
-		fmt.Fprintf(&b, "○ %s %s  \n", s.DepartAt.UTC().Format(timeLayout), s.From)
+		fmt.Fprintf(&b, "○ %s %s%s  \n", s.DepartAt.UTC().Format(timeLayout), s.From, terminal(s.FromTerminal))
 		fmt.Fprintf(&b, "│  \n")
 		fmt.Fprintf(&b, "│ Travel Time: %s  \n", formatDuration(s.ArriveAt.Sub(s.DepartAt)))
 		fmt.Fprintf(&b, "│  \n")
-		fmt.Fprintf(&b, "○ %s\n", s.ArriveAt.UTC().Format(timeLayout))
-		fmt.Fprintf(&b, "│ %s · %s · %s\n", emptyDash(s.Carrier), emptyDash(s.FlightNo), emptyDash(s.Cabin))
+		fmt.Fprintf(&b, "○ %s %s%s\n", s.ArriveAt.UTC().Format(timeLayout), s.To, terminal(s.ToTerminal))
+		fmt.Fprintf(&b, "│ %s · %s · %s\n", emptyDash(s.Carrier), emptyDash(s.FlightNo), emptyDash(cabinName(s.Cabin)))
+		if line := aircraftLine(s); line != "" {
+			fmt.Fprintf(&b, "│ %s\n", line)
+		}
+		for _, a := range s.Amenities {
+			fmt.Fprintf(&b, "│ %s %s\n", amenityIcon(a), a)
+		}
 		fmt.Fprintf(&b, "│\n")
 		if len(offer.Segments) > i+1 {
 			next := offer.Segments[i+1]
 			layover := next.DepartAt.Sub(s.ArriveAt)
 
-			fmt.Fprintf(&b, "────────────────────────────────────────────────────────────────\n")
+			fmt.Fprintf(&b, "%s\n", rule)
 			fmt.Fprintf(&b, "%s layover • %s\n", formatDuration(layover), s.To)
-			fmt.Fprintf(&b, "────────────────────────────────────────────────────────────────\n")
+			for _, w := range warnings[i+1] {
+				fmt.Fprintf(&b, "⚠ %s\n", w.message)
+			}
+			fmt.Fprintf(&b, "%s\n", rule)
 		}
 	}
-	b.WriteString("```\n\n")
+	return b.String()
+}
 
-	routeDetails, err := renderer.Render(b.String())
-	if err != nil {
+// terminal is " · Terminal X" when the terminal is known.
+func terminal(t string) string {
+	if t == "" {
 		return ""
 	}
+	return " · Terminal " + t
+}
 
-	fillView := lipgloss.JoinVertical(lipgloss.Left, header, departureAndPrice, routeDetails)
-	return lipgloss.NewStyle().Render(fillView)
+// cabinName turns "premium_economy" into "Premium economy".
+func cabinName(cabin string) string {
+	if cabin == "" {
+		return ""
+	}
+	cabin = strings.ReplaceAll(cabin, "_", " ")
+	return strings.ToUpper(cabin[:1]) + cabin[1:]
+}
+
+// aircraftLine is the aircraft, legroom and seat of a segment, as far as
+// they are known.
+func aircraftLine(s types.Segment) string {
+	var parts []string
+	if s.Aircraft != "" {
+		parts = append(parts, s.Aircraft)
+	}
+	if s.Legroom != "" {
+		parts = append(parts, s.Legroom+" legroom")
+	}
+	if s.Seat != "" {
+		parts = append(parts, s.Seat)
+	}
+	return strings.Join(parts, " · ")
+}
+
+var amenityIcons = []struct{ keyword, icon string }{
+	{"wi-fi", "📶"},
+	{"wifi", "📶"},
+	{"power", "🔌"},
+	{"usb", "🔌"},
+	{"outlet", "🔌"},
+	{"stream", "📺"},
+	{"video", "🎬"},
+	{"entertainment", "🎬"},
+}
+
+// amenityIcon picks an icon for an amenity from how it is worded.
+func amenityIcon(amenity string) string {
+	lower := strings.ToLower(amenity)
+	for _, a := range amenityIcons {
+		if strings.Contains(lower, a.keyword) {
+			return a.icon
+		}
+	}
+	return "•"
 }
 
 func offerMarkdown(offer types.FlightOffer) string {
 	var b strings.Builder
 
 	const dateLayout = "Mon, 02 Jan 2006"
-	const timeLayout = "15:04 MST"
 
 	// Summary
 	fmt.Fprintf(&b, "### Selected Journey: %s\n\n", routeLine(offer.Segments))
 	fmt.Fprintf(&b, "**Depature %s**\n\n", offer.Segments[0].DepartAt.UTC().Format(dateLayout))
-	fmt.Fprintf(&b, "**Price (%s): %d**\n\n", offer.TotalPrice.Currency, offer.TotalPrice.Amount)
+	fmt.Fprintf(&b, "**Price: %s**\n\n", priceLine(offer))
+	b.WriteString(priceBreakdown(offer))
+	b.WriteString(emissionsLine(offer))
 
 	// Segments
 	if len(offer.Segments) == 0 {
@@ -158,30 +229,7 @@ func offerMarkdown(offer types.FlightOffer) string {
 	}
 
 	b.WriteString("```text\n")
-	for i, s := range offer.Segments {
-
-		if i != 0 {
-			fmt.Fprintf(&b, "│\n")
-		}
-
-		fmt.Fprintf(&b, "○ %s %s  \n", s.DepartAt.UTC().Format(timeLayout), s.From)
-		fmt.Fprintf(&b, "│  \n")
-		fmt.Fprintf(&b, "│ Travel Time: %s  \n", formatDuration(s.ArriveAt.Sub(s.DepartAt)))
-		fmt.Fprintf(&b, "│  \n")
-		fmt.Fprintf(&b, "○ %s\n", s.ArriveAt.UTC().Format(timeLayout))
-		fmt.Fprintf(&b, "│ %s · %s · %s\n", emptyDash(s.Carrier), emptyDash(s.FlightNo), emptyDash(s.Cabin))
-		fmt.Fprintf(&b, "│\n")
-		if len(offer.Segments) > i+1 {
-
-			next := offer.Segments[i+1]
-			layover := next.DepartAt.Sub(s.ArriveAt)
-
-			fmt.Fprintf(&b, "│────────────────────────────────────────────────────────────────\n")
-			fmt.Fprintf(&b, "│ Layover: %s\n", formatDuration(layover))
-			fmt.Fprintf(&b, "│────────────────────────────────────────────────────────────────\n")
-		}
-	}
-
+	b.WriteString(segmentTimeline(offer, 64))
 	b.WriteString("```\n\n")
 
 	b.WriteString("\n\n*(Press **b** to go back)*\n")
@@ -222,3 +270,123 @@ func emptyDash(s string) string {
 	}
 	return s
 }
+
+// priceLine is the display price, followed by the quoted one when it was
+// converted.
+func priceLine(offer types.FlightOffer) string {
+	if offer.Converted == nil {
+		return utils.FormatMoney(offer.TotalPrice)
+	}
+	return fmt.Sprintf("≈%s (%s)", utils.FormatMoney(offer.Converted.Price), utils.FormatMoney(offer.TotalPrice))
+}
+
+var travelerTypes = map[string]string{
+	"adult":         "Adult",
+	"child":         "Child",
+	"senior":        "Senior",
+	"young":         "Youth",
+	"seated_infant": "Infant (seat)",
+	"held_infant":   "Infant (lap)",
+}
+
+// priceBreakdown explains the price as markdown: the rate it was converted
+// at, and how the fare splits into base, taxes and fees when the provider
+// says.
+func priceBreakdown(offer types.FlightOffer) string {
+	var b strings.Builder
+	if c := offer.Converted; c != nil {
+		fmt.Fprintf(&b, "> 1 %s = %s %s, rates of %s from %s\n\n",
+			offer.TotalPrice.Currency, c.Rate, c.Price.Currency, c.RateDate.Format(time.DateOnly), c.Source)
+	}
+	b.WriteString(bagsSummary(offer))
+	f := offer.Fare
+	if f == nil {
+		return b.String()
+	}
+
+	total := offer.TotalPrice
+	b.WriteString("| Fare | |\n| --- | ---: |\n")
+	fmt.Fprintf(&b, "| Base fare | %s |\n", utils.FormatMoney(f.Base))
+	fmt.Fprintf(&b, "| Taxes | %s · %s |\n", utils.FormatMoney(f.Taxes), share(f.Taxes, total))
+	if f.Fees.Amount != 0 {
+		fmt.Fprintf(&b, "| Fees | %s · %s |\n", utils.FormatMoney(f.Fees), share(f.Fees, total))
+	}
+	fmt.Fprintf(&b, "| **Total** | **%s** |\n", utils.FormatMoney(total))
+	if f.GrandTotal != total {
+		fmt.Fprintf(&b, "| With extras | %s |\n", utils.FormatMoney(f.GrandTotal))
+	}
+	b.WriteString("\n")
+
+	if len(f.Travelers) > 1 {
+		b.WriteString("| Traveler | Base | Taxes | Total |\n| --- | ---: | ---: | ---: |\n")
+		for _, t := range f.Travelers {
+			label := travelerTypes[t.Type]
+			if label == "" {
+				label = emptyDash(t.Type)
+			}
+			fmt.Fprintf(&b, "| %s %s | %s | %s | %s |\n", label, t.ID,
+				utils.FormatMoney(t.Base), utils.FormatMoney(t.Taxes), utils.FormatMoney(t.Total))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// bagsSummary says what the fare includes and what bringing your luggage
+// comes to.
+func bagsSummary(offer types.FlightOffer) string {
+	var b strings.Builder
+	switch a := offer.Baggage; {
+	case a == nil:
+		b.WriteString("Bags included: unknown  \n")
+	case *a == (types.Baggage{}):
+		b.WriteString("Bags included: none  \n")
+	default:
+		fmt.Fprintf(&b, "Bags included: %s  \n", Luggage{CarryOn: a.CarryOn, Checked: a.Checked})
+	}
+	if e := offer.Estimate; e != nil {
+		fmt.Fprintf(&b, "With %s: **%s**", e.Luggage, utils.FormatMoney(e.Total))
+		if e.BagFees.Amount > 0 {
+			fmt.Fprintf(&b, ", including %s in %s bag fees", utils.FormatMoney(e.BagFees), e.Table)
+		}
+		if !e.Complete {
+			b.WriteString(" (some fees unknown)")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// emissionsLine says how much CO2 the trip emits per traveler and how that
+// compares with what is typical for the route.
+func emissionsLine(offer types.FlightOffer) string {
+	e := offer.Emissions
+	if e == nil {
+		return "CO2: unknown\n\n"
+	}
+	line := fmt.Sprintf("CO2: %s per traveler", utils.FormatEmissions(e))
+	if diff, ok := e.VsTypical(); ok {
+		switch {
+		case diff < 0:
+			line += fmt.Sprintf(", %d%% less than typical for this route", -diff)
+		case diff > 0:
+			line += fmt.Sprintf(", %d%% more than typical for this route", diff)
+		default:
+			line += ", typical for this route"
+		}
+	}
+	if e.Estimated {
+		line += " (estimated from distance and aircraft)"
+	}
+	return line + "\n\n"
+}
+
+// share is part as a whole percentage of total, or "-" when there is no
+// total in the same currency.
+func share(part, total types.Money) string {
+	if total.Amount == 0 || part.Currency != total.Currency {
+		return "-"
+	}
+	return fmt.Sprintf("%d%%", (part.Amount*100+total.Amount/2)/total.Amount)
+}
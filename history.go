@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/justinm35/flyctl/store"
+	"github.com/justinm35/flyctl/styles"
+	"github.com/justinm35/flyctl/types"
+)
+
+// maxHistory is how many searches are kept with their offers. Price
+// observations of older searches are kept.
+const maxHistory = 50
+
+// historyEntry is one executed search.
+type historyEntry struct {
+	store.Search
+}
+
+func (e historyEntry) query() searchQuery {
+	return searchQuery{
+		Origin:        e.Origin,
+		Destination:   e.Destination,
+		DepartureDate: e.DepartureDate,
+		Provider:      e.Provider,
+	}
+}
+
+func (e historyEntry) label() string { return e.query().describe() }
+
+func (e historyEntry) hint() string {
+	return fmt.Sprintf("%s · %d offers · %s", e.Provider, e.OfferCount, e.SearchedAt.Local().Format("Jan 2 15:04"))
+}
+
+func (e historyEntry) FilterValue() string { return e.query().describe() }
+
+// loadHistory returns past searches, newest first.
+func loadHistory() ([]historyEntry, error) {
+	db, err := openStore()
+	if err != nil {
+		return nil, err
+	}
+	searches, err := db.Searches()
+	history := make([]historyEntry, len(searches))
+	for i, s := range searches {
+		history[i] = historyEntry{s}
+	}
+	return history, err
+}
+
+// loadSnapshot returns the offers a search returned, as they were returned.
+func loadSnapshot(id int64) ([]types.FlightOffer, error) {
+	db, err := openStore()
+	if err != nil {
+		return nil, err
+	}
+	return db.SearchOffers(id)
+}
+
+// latestSearch is the most recent search, if there is one.
+func latestSearch() (historyEntry, bool, error) {
+	history, err := loadHistory()
+	if err != nil || len(history) == 0 {
+		return historyEntry{}, false, err
+	}
+	return history[0], true, nil
+}
+
+// recordSearch stores a search and the offers it returned.
+func recordSearch(query searchQuery, offers []types.FlightOffer, at time.Time) error {
+	db, err := openStore()
+	if err != nil {
+		return err
+	}
+	_, err = db.RecordSearch(store.Search{
+		Origin:        query.Origin,
+		Destination:   query.Destination,
+		DepartureDate: query.DepartureDate,
+		Provider:      query.Provider,
+		SearchedAt:    at,
+	}, offers)
+	if err != nil {
+		return err
+	}
+	return db.PruneSearches(maxHistory)
+}
+
+// lowestPrice is the cheapest price in currency seen for a route since the
+// given time, across every search of it.
+func lowestPrice(query searchQuery, currency string, since time.Time) (store.PriceObservation, bool, error) {
+	db, err := openStore()
+	if err != nil {
+		return store.PriceObservation{}, false, err
+	}
+	return db.CheapestSince(query.Origin, query.Destination, currency, since)
+}
+
+func deleteHistoryEntry(id int64) error {
+	db, err := openStore()
+	if err != nil {
+		return err
+	}
+	return db.DeleteSearch(id)
+}
+
+// HistoryState is the list of past searches. Like the comparison, it replaces
+// all panes while open.
+type HistoryState struct {
+	list list.Model
+}
+
+func newHistoryState(keys historyKeyMap) HistoryState {
+	l := list.New(nil, lineDelegate{}, 80, 20)
+	l.SetShowTitle(false)
+	l.SetShowStatusBar(false)
+	l.SetShowHelp(false)
+	l.SetFilteringEnabled(false)
+	l.DisableQuitKeybindings()
+	h := HistoryState{list: l}
+	h.setKeys(keys)
+	return h
+}
+
+func (h *HistoryState) setKeys(keys historyKeyMap) {
+	h.list.KeyMap.CursorUp = keys.Up
+	h.list.KeyMap.CursorDown = keys.Down
+	h.list.KeyMap.PrevPage = keys.PageUp
+	h.list.KeyMap.NextPage = keys.PageDown
+}
+
+func (h *HistoryState) refresh() error {
+	history, err := loadHistory()
+	var items []list.Item
+	for _, entry := range history {
+		items = append(items, entry)
+	}
+	h.list.SetItems(items)
+	return err
+}
+
+// resize fits the list to the area normally taken by the panes, less the
+// title and key hint lines.
+func (h *HistoryState) resize(width, height int) {
+	h.list.SetSize(width, max(height-4, 1))
+}
+
+func (m *Model) openHistory() tea.Cmd {
+	err := m.history.refresh()
+	m.history.list.Select(0)
+	m.screen = screenHistory
+	m.applyLayout()
+	if err != nil {
+		return m.setStatus(err.Error())
+	}
+	return nil
+}
+
+// restoreLatestSearch loads the most recent search and its results into
+// the active tab.
+func (m *Model) restoreLatestSearch() error {
+	latest, ok, err := latestSearch()
+	if err != nil || !ok {
+		return err
+	}
+	offers, err := loadSnapshot(latest.ID)
+	if err != nil {
+		return err
+	}
+	m.screenSearch.apply(latest.query())
+	m.screenResults.setOffers(offers)
+	return nil
+}
+
+// openSnapshot shows the results of a past search exactly as they were
+// returned, in the active tab.
+func (m *Model) openSnapshot(entry historyEntry) tea.Cmd {
+	offers, err := loadSnapshot(entry.ID)
+	if err != nil {
+		return m.setStatus(err.Error())
+	}
+	m.screenSearch.apply(entry.query())
+	m.screenResults.setOffers(offers)
+	m.screen = screenResults
+	m.focusPane(screenResults)
+	return m.setStatus("showing results from " + entry.SearchedAt.Local().Format("Mon Jan 2 15:04"))
+}
+
+func updateHistory(m Model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	if km, ok := msg.(tea.KeyMsg); ok {
+		entry, hasSelection := m.history.list.SelectedItem().(historyEntry)
+		switch {
+		case key.Matches(km, m.keys.History.Back):
+			m.screen = screenResults
+			return m, nil
+		case key.Matches(km, m.keys.History.Open) && hasSelection:
+			return m, m.openSnapshot(entry)
+		case key.Matches(km, m.keys.History.Rerun) && hasSelection:
+			m.screenSearch.apply(entry.query())
+			m.screen = screenSearch
+			m.focusPane(screenSearch)
+			return m, m.startSearch()
+		case key.Matches(km, m.keys.History.Delete) && hasSelection:
+			if err := deleteHistoryEntry(entry.ID); err != nil {
+				return m, m.setStatus(err.Error())
+			}
+			if err := m.history.refresh(); err != nil {
+				return m, m.setStatus(err.Error())
+			}
+			return m, nil
+		}
+	}
+	var cmd tea.Cmd
+	m.history.list, cmd = m.history.list.Update(msg)
+	return m, cmd
+}
+
+func viewHistory(m Model) string {
+	theme := styles.Active()
+	muted := lipgloss.NewStyle().Foreground(theme.Muted)
+	s := theme.Title().Render("[Search history]") + "\n\n"
+	if len(m.history.list.Items()) == 0 {
+		s += muted.Render("No searches yet.") + "\n\n"
+	} else {
+		s += m.history.list.View() + "\n"
+	}
+	s += muted.Render(fmt.Sprintf(
+		"open results (%s) • run again (%s) • delete (%s) • back (%s)",
+		m.keys.History.Open.Help().Key, m.keys.History.Rerun.Help().Key,
+		m.keys.History.Delete.Help().Key, m.keys.History.Back.Help().Key,
+	))
+	return s
+}
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/spf13/viper"
+)
+
+// keyMap holds every binding in the app, grouped by the pane it applies to.
+// Bindings can be overridden in config.yaml:
+//
+//	keymap: vim
+//	keys:
+//	  global:
+//	    quit: ["ctrl+c", "ctrl+q"]
+//	  results:
+//	    star: ["space", "s"]
+type keyMap struct {
+	Global  globalKeyMap
+	Search  searchKeyMap
+	Results resultsKeyMap
+	Details detailsKeyMap
+	Compare compareKeyMap
+	History historyKeyMap
+	Setup   setupKeyMap
+}
+
+type globalKeyMap struct {
+	NextPane key.Binding
+	PrevPane key.Binding
+	NewTab   key.Binding
+	CloseTab key.Binding
+	NextTab  key.Binding
+	PrevTab  key.Binding
+	Maximize key.Binding
+	Palette  key.Binding
+	History  key.Binding
+	Logs     key.Binding
+	Help     key.Binding
+	Suspend  key.Binding
+	Quit     key.Binding
+}
+
+type searchKeyMap struct {
+	NextField   key.Binding
+	PrevField   key.Binding
+	Submit      key.Binding
+	Save        key.Binding
+	OpenSaved   key.Binding
+	DeleteSaved key.Binding
+	Cancel      key.Binding
+}
+
+type resultsKeyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+	Top      key.Binding
+	Bottom   key.Binding
+	Star     key.Binding
+	Mark     key.Binding
+	Compare  key.Binding
+}
+
+type detailsKeyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+	Back     key.Binding
+}
+
+type compareKeyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+	Back     key.Binding
+}
+
+type historyKeyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+	Open     key.Binding
+	Rerun    key.Binding
+	Delete   key.Binding
+	Back     key.Binding
+}
+
+type setupKeyMap struct {
+	NextField key.Binding
+	PrevField key.Binding
+	Provider  key.Binding
+	Save      key.Binding
+	Skip      key.Binding
+}
+
+func defaultKeyMap() keyMap {
+	return keyMap{
+		Global: globalKeyMap{
+			NextPane: key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "next pane")),
+			PrevPane: key.NewBinding(key.WithKeys("shift+tab"), key.WithHelp("shift+tab", "previous pane")),
+			NewTab:   key.NewBinding(key.WithKeys("ctrl+t"), key.WithHelp("ctrl+t", "new tab")),
+			CloseTab: key.NewBinding(key.WithKeys("ctrl+w"), key.WithHelp("ctrl+w", "close tab")),
+			NextTab:  key.NewBinding(key.WithKeys("ctrl+right", "alt+]"), key.WithHelp("ctrl+→/alt+]", "next tab")),
+			PrevTab:  key.NewBinding(key.WithKeys("ctrl+left", "alt+["), key.WithHelp("ctrl+←/alt+[", "previous tab")),
+			Maximize: key.NewBinding(key.WithKeys("ctrl+f"), key.WithHelp("ctrl+f", "maximize pane")),
+			Palette:  key.NewBinding(key.WithKeys("ctrl+p"), key.WithHelp("ctrl+p", "commands")),
+			History:  key.NewBinding(key.WithKeys("ctrl+r"), key.WithHelp("ctrl+r", "search history")),
+			Logs:     key.NewBinding(key.WithKeys("ctrl+g"), key.WithHelp("ctrl+g", "toggle log")),
+			Help:     key.NewBinding(key.WithKeys("?", "f1"), key.WithHelp("?/f1", "help")),
+			Suspend:  key.NewBinding(key.WithKeys("ctrl+z"), key.WithHelp("ctrl+z", "suspend")),
+			Quit:     key.NewBinding(key.WithKeys("ctrl+c"), key.WithHelp("ctrl+c", "quit")),
+		},
+		Search: searchKeyMap{
+			NextField:   key.NewBinding(key.WithKeys("down"), key.WithHelp("↓", "next field")),
+			PrevField:   key.NewBinding(key.WithKeys("up"), key.WithHelp("↑", "previous field")),
+			Submit:      key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "search")),
+			Save:        key.NewBinding(key.WithKeys("ctrl+s"), key.WithHelp("ctrl+s", "save search")),
+			OpenSaved:   key.NewBinding(key.WithKeys("ctrl+o"), key.WithHelp("ctrl+o", "saved searches")),
+			DeleteSaved: key.NewBinding(key.WithKeys("d", "delete"), key.WithHelp("d", "delete saved search")),
+			Cancel:      key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "back")),
+		},
+		Results: resultsKeyMap{
+			Up:       key.NewBinding(key.WithKeys("up"), key.WithHelp("↑", "up")),
+			Down:     key.NewBinding(key.WithKeys("down"), key.WithHelp("↓", "down")),
+			PageUp:   key.NewBinding(key.WithKeys("pgup"), key.WithHelp("pgup", "page up")),
+			PageDown: key.NewBinding(key.WithKeys("pgdown"), key.WithHelp("pgdn", "page down")),
+			Top:      key.NewBinding(key.WithKeys("home"), key.WithHelp("home", "first offer")),
+			Bottom:   key.NewBinding(key.WithKeys("end"), key.WithHelp("end", "last offer")),
+			Star:     key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "star offer")),
+			Mark:     key.NewBinding(key.WithKeys("m"), key.WithHelp("m", "mark for compare")),
+			Compare:  key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "compare marked")),
+		},
+		Details: detailsKeyMap{
+			Up:       key.NewBinding(key.WithKeys("up"), key.WithHelp("↑", "scroll up")),
+			Down:     key.NewBinding(key.WithKeys("down"), key.WithHelp("↓", "scroll down")),
+			PageUp:   key.NewBinding(key.WithKeys("pgup"), key.WithHelp("pgup", "page up")),
+			PageDown: key.NewBinding(key.WithKeys("pgdown"), key.WithHelp("pgdn", "page down")),
+			Back:     key.NewBinding(key.WithKeys("b"), key.WithHelp("b", "back to results")),
+		},
+		Compare: compareKeyMap{
+			Up:       key.NewBinding(key.WithKeys("up"), key.WithHelp("↑", "scroll up")),
+			Down:     key.NewBinding(key.WithKeys("down"), key.WithHelp("↓", "scroll down")),
+			PageUp:   key.NewBinding(key.WithKeys("pgup"), key.WithHelp("pgup", "page up")),
+			PageDown: key.NewBinding(key.WithKeys("pgdown"), key.WithHelp("pgdn", "page down")),
+			Back:     key.NewBinding(key.WithKeys("esc", "b"), key.WithHelp("esc/b", "close comparison")),
+		},
+		History: historyKeyMap{
+			Up:       key.NewBinding(key.WithKeys("up"), key.WithHelp("↑", "up")),
+			Down:     key.NewBinding(key.WithKeys("down"), key.WithHelp("↓", "down")),
+			PageUp:   key.NewBinding(key.WithKeys("pgup"), key.WithHelp("pgup", "page up")),
+			PageDown: key.NewBinding(key.WithKeys("pgdown"), key.WithHelp("pgdn", "page down")),
+			Open:     key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "open results")),
+			Rerun:    key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "run again")),
+			Delete:   key.NewBinding(key.WithKeys("d", "delete"), key.WithHelp("d", "delete")),
+			Back:     key.NewBinding(key.WithKeys("esc", "b"), key.WithHelp("esc/b", "close history")),
+		},
+		Setup: setupKeyMap{
+			NextField: key.NewBinding(key.WithKeys("down"), key.WithHelp("↓", "next field")),
+			PrevField: key.NewBinding(key.WithKeys("up"), key.WithHelp("↑", "previous field")),
+			Provider:  key.NewBinding(key.WithKeys("left", "right"), key.WithHelp("←/→", "change provider")),
+			Save:      key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "save")),
+			Skip:      key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "skip")),
+		},
+	}
+}
+
+// vimKeyMap layers vim-style navigation on top of the defaults. Arrow keys
+// keep working; the search pane keeps plain letters free for typing.
+func vimKeyMap() keyMap {
+	k := defaultKeyMap()
+	addKeys(&k.Global.NextPane, "ctrl+l")
+	addKeys(&k.Global.PrevPane, "ctrl+h")
+	addKeys(&k.Search.NextField, "ctrl+j")
+	addKeys(&k.Search.PrevField, "ctrl+k")
+	addKeys(&k.Results.Up, "k")
+	addKeys(&k.Results.Down, "j")
+	addKeys(&k.Results.PageUp, "ctrl+u")
+	addKeys(&k.Results.PageDown, "ctrl+d")
+	addKeys(&k.Results.Top, "g")
+	addKeys(&k.Results.Bottom, "G")
+	addKeys(&k.Details.Up, "k")
+	addKeys(&k.Details.Down, "j")
+	addKeys(&k.Details.PageUp, "ctrl+u")
+	addKeys(&k.Details.PageDown, "ctrl+d")
+	addKeys(&k.Compare.Up, "k")
+	addKeys(&k.Compare.Down, "j")
+	addKeys(&k.Compare.PageUp, "ctrl+u")
+	addKeys(&k.Compare.PageDown, "ctrl+d")
+	addKeys(&k.History.Up, "k")
+	addKeys(&k.History.Down, "j")
+	addKeys(&k.History.PageUp, "ctrl+u")
+	addKeys(&k.History.PageDown, "ctrl+d")
+	return k
+}
+
+// loadKeyMap builds the keymap from the configured preset and applies any
+// per-binding overrides from the "keys" section of the config.
+func loadKeyMap() keyMap {
+	var k keyMap
+	switch preset := viper.GetString("keymap"); preset {
+	case "", "default":
+		k = defaultKeyMap()
+	case "vim":
+		k = vimKeyMap()
+	default:
+		slog.Warn("unknown keymap preset, using default", "preset", preset)
+		k = defaultKeyMap()
+	}
+
+	for name, b := range k.named() {
+		path := "keys." + name
+		if !viper.IsSet(path) {
+			continue
+		}
+		keys := viper.GetStringSlice(path)
+		if len(keys) == 0 {
+			b.Unbind()
+			continue
+		}
+		for i := range keys {
+			keys[i] = normalizeKey(keys[i])
+		}
+		b.SetKeys(keys...)
+		b.SetHelp(helpKeys(keys), b.Help().Desc)
+	}
+
+	return k
+}
+
+// applyKeys hands the key map to the components that keep their own copy,
+// in every tab.
+func (m *Model) applyKeys() {
+	m.screenResults.keys = m.keys.Results
+	m.screenFlightDetails.viewport.KeyMap = m.keys.Details.viewportKeyMap()
+	for i := range m.tabs {
+		m.tabs[i].results.keys = m.keys.Results
+		m.tabs[i].flightDetails.viewport.KeyMap = m.keys.Details.viewportKeyMap()
+	}
+	m.compare.viewport.KeyMap = m.keys.Compare.viewportKeyMap()
+	m.history.setKeys(m.keys.History)
+}
+
+// named maps the config name of every binding ("<pane>.<action>") to the
+// binding itself.
+func (k *keyMap) named() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"global.next_pane":  &k.Global.NextPane,
+		"global.prev_pane":  &k.Global.PrevPane,
+		"global.new_tab":    &k.Global.NewTab,
+		"global.close_tab":  &k.Global.CloseTab,
+		"global.next_tab":   &k.Global.NextTab,
+		"global.prev_tab":   &k.Global.PrevTab,
+		"global.maximize":   &k.Global.Maximize,
+		"global.palette":    &k.Global.Palette,
+		"global.history":    &k.Global.History,
+		"global.logs":       &k.Global.Logs,
+		"global.help":       &k.Global.Help,
+		"global.suspend":    &k.Global.Suspend,
+		"global.quit":       &k.Global.Quit,
+		"search.next_field": &k.Search.NextField,
+		"search.prev_field": &k.Search.PrevField,
+		"search.submit":     &k.Search.Submit,
+		"search.save":       &k.Search.Save,
+		"search.open_saved": &k.Search.OpenSaved,
+		"search.delete":     &k.Search.DeleteSaved,
+		"search.cancel":     &k.Search.Cancel,
+		"results.up":        &k.Results.Up,
+		"results.down":      &k.Results.Down,
+		"results.page_up":   &k.Results.PageUp,
+		"results.page_down": &k.Results.PageDown,
+		"results.top":       &k.Results.Top,
+		"results.bottom":    &k.Results.Bottom,
+		"results.star":      &k.Results.Star,
+		"results.mark":      &k.Results.Mark,
+		"results.compare":   &k.Results.Compare,
+		"details.up":        &k.Details.Up,
+		"details.down":      &k.Details.Down,
+		"details.page_up":   &k.Details.PageUp,
+		"details.page_down": &k.Details.PageDown,
+		"details.back":      &k.Details.Back,
+		"compare.up":        &k.Compare.Up,
+		"compare.down":      &k.Compare.Down,
+		"compare.page_up":   &k.Compare.PageUp,
+		"compare.page_down": &k.Compare.PageDown,
+		"compare.back":      &k.Compare.Back,
+		"history.up":        &k.History.Up,
+		"history.down":      &k.History.Down,
+		"history.page_up":   &k.History.PageUp,
+		"history.page_down": &k.History.PageDown,
+		"history.open":      &k.History.Open,
+		"history.rerun":     &k.History.Rerun,
+		"history.delete":    &k.History.Delete,
+		"history.back":      &k.History.Back,
+		"setup.next_field":  &k.Setup.NextField,
+		"setup.prev_field":  &k.Setup.PrevField,
+		"setup.provider":    &k.Setup.Provider,
+		"setup.save":        &k.Setup.Save,
+		"setup.skip":        &k.Setup.Skip,
+	}
+}
+
+// paneHelp returns the binding groups shown in the help overlay for a pane.
+func (k keyMap) paneHelp(s screen) [][]key.Binding {
+	global := []key.Binding{
+		k.Global.NextPane, k.Global.PrevPane, k.Global.NewTab, k.Global.CloseTab, k.Global.NextTab, k.Global.PrevTab,
+		k.Global.Maximize, k.Global.Palette, k.Global.History, k.Global.Logs, k.Global.Help, k.Global.Suspend, k.Global.Quit,
+	}
+	switch s {
+	case screenSearch:
+		return [][]key.Binding{
+			{k.Search.NextField, k.Search.PrevField, k.Search.Submit},
+			{k.Search.Save, k.Search.OpenSaved, k.Search.DeleteSaved, k.Search.Cancel},
+			global,
+		}
+	case screenResults:
+		return [][]key.Binding{
+			{k.Results.Up, k.Results.Down, k.Results.PageUp, k.Results.PageDown},
+			{k.Results.Top, k.Results.Bottom, k.Results.Star, k.Results.Mark, k.Results.Compare},
+			global,
+		}
+	case screenFlightDetails:
+		return [][]key.Binding{{k.Details.Up, k.Details.Down, k.Details.PageUp, k.Details.PageDown, k.Details.Back}, global}
+	case screenCompare:
+		return [][]key.Binding{{k.Compare.Up, k.Compare.Down, k.Compare.PageUp, k.Compare.PageDown, k.Compare.Back}, global}
+	case screenHistory:
+		return [][]key.Binding{
+			{k.History.Up, k.History.Down, k.History.PageUp, k.History.PageDown},
+			{k.History.Open, k.History.Rerun, k.History.Delete, k.History.Back},
+			global,
+		}
+	case screenSetup:
+		return [][]key.Binding{{k.Setup.NextField, k.Setup.PrevField, k.Setup.Provider, k.Setup.Save, k.Setup.Skip}, global}
+	default:
+		return [][]key.Binding{global}
+	}
+}
+
+// shortHelp is the list of bindings shown in the bottom bar.
+func (k keyMap) shortHelp() []key.Binding {
+	return []key.Binding{k.Global.Quit, k.Global.NextPane, k.Global.Palette, k.Global.Help}
+}
+
+func (k compareKeyMap) viewportKeyMap() viewport.KeyMap {
+	return detailsKeyMap{Up: k.Up, Down: k.Down, PageUp: k.PageUp, PageDown: k.PageDown}.viewportKeyMap()
+}
+
+func (k detailsKeyMap) viewportKeyMap() viewport.KeyMap {
+	return viewport.KeyMap{
+		Up:           k.Up,
+		Down:         k.Down,
+		PageUp:       k.PageUp,
+		PageDown:     k.PageDown,
+		HalfPageUp:   key.NewBinding(key.WithDisabled()),
+		HalfPageDown: key.NewBinding(key.WithDisabled()),
+		Left:         key.NewBinding(key.WithDisabled()),
+		Right:        key.NewBinding(key.WithDisabled()),
+	}
+}
+
+func addKeys(b *key.Binding, keys ...string) {
+	all := append(b.Keys(), keys...)
+	b.SetKeys(all...)
+	b.SetHelp(helpKeys(all), b.Help().Desc)
+}
+
+// normalizeKey maps config spellings to the strings bubbletea reports.
+func normalizeKey(k string) string {
+	if k == " " {
+		return k
+	}
+	switch strings.ToLower(strings.TrimSpace(k)) {
+	case "space":
+		return " "
+	case "pagedown", "pgdn":
+		return "pgdown"
+	case "pageup":
+		return "pgup"
+	}
+	return strings.TrimSpace(k)
+}
+
+func helpKeys(keys []string) string {
+	labels := make([]string, 0, len(keys))
+	for _, k := range keys {
+		switch k {
+		case " ":
+			labels = append(labels, "space")
+		case "up":
+			labels = append(labels, "↑")
+		case "down":
+			labels = append(labels, "↓")
+		default:
+			labels = append(labels, k)
+		}
+	}
+	return strings.Join(labels, "/")
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/justinm35/flyctl/styles"
+)
+
+// layoutMode describes how the panes are arranged on screen.
+type layoutMode int
+
+const (
+	layoutStacked    layoutMode = iota // every pane full width, one above the other
+	layoutSplit                        // results on top, search and details side by side below
+	layoutSideBySide                   // search, results and details as three columns
+	layoutMaximized                    // only the focused pane is shown
+)
+
+// Breakpoints and fixed sizes (outer sizes, borders included).
+const (
+	stackedMaxWidth    = 100 // below this width panes are stacked
+	sideBySideMinWidth = 180 // from this width panes become columns
+	bottomBarHeight    = 1
+	minPaneHeight      = 6
+	searchPaneHeight   = 14
+	minSearchPaneWidth = 40
+	maxSearchPaneWidth = 70
+)
+
+// paneRect is the position and outer size of a pane, in terminal cells.
+type paneRect struct {
+	x, y          int
+	width, height int
+}
+
+func (r paneRect) visible() bool { return r.width > 2 && r.height > 2 }
+
+func (r paneRect) innerWidth() int { return max(r.width-2, 0) }
+
+func (r paneRect) innerHeight() int { return max(r.height-2, 0) }
+
+func (r paneRect) contains(x, y int) bool {
+	return r.visible() && x >= r.x && x < r.x+r.width && y >= r.y && y < r.y+r.height
+}
+
+// layout holds the computed rectangles for a given terminal size.
+type layout struct {
+	mode  layoutMode
+	panes [screenCount]paneRect
+	tabs  paneRect
+	full  paneRect // everything between the tab strip and the log pane or bottom bar
+	logs  paneRect // empty unless the log pane is shown
+	bar   paneRect
+}
+
+func (l layout) pane(s screen) paneRect { return l.panes[s] }
+
+// computeLayout picks a layout mode for the terminal size and places the panes
+// between the tab strip and the bottom bar, leaving room for the log pane
+// above the bar when it is shown and the terminal is tall enough.
+func computeLayout(width, height int, focused screen, maximized, showLogs bool) layout {
+	logsH := 0
+	if showLogs && height-tabBarHeight-bottomBarHeight-logPaneHeight >= minPaneHeight {
+		logsH = logPaneHeight
+	}
+
+	l := placePanes(width, max(height-tabBarHeight-logsH, 0), focused, maximized)
+	for i := range l.panes {
+		l.panes[i].y += tabBarHeight
+	}
+	l.logs = paneRect{x: 0, y: l.bar.y + tabBarHeight, width: width, height: logsH}
+	l.bar.y += tabBarHeight + logsH
+	l.tabs = paneRect{x: 0, y: 0, width: width, height: tabBarHeight}
+	l.full = paneRect{x: 0, y: tabBarHeight, width: width, height: max(height-tabBarHeight-logsH-bottomBarHeight, 0)}
+	return l
+}
+
+// placePanes lays the panes out from the top of the given area. When the area
+// is too small for the chosen mode, or maximized is set, only the focused
+// pane is shown.
+func placePanes(width, height int, focused screen, maximized bool) layout {
+	l := layout{}
+	avail := max(height-bottomBarHeight, 0)
+	l.bar = paneRect{x: 0, y: avail, width: width, height: bottomBarHeight}
+
+	switch {
+	case width >= sideBySideMinWidth:
+		l.mode = layoutSideBySide
+	case width >= stackedMaxWidth:
+		l.mode = layoutSplit
+	default:
+		l.mode = layoutStacked
+	}
+	if maximized {
+		l.mode = layoutMaximized
+	}
+
+	switch l.mode {
+	case layoutSideBySide:
+		searchW := clamp(width/5, minSearchPaneWidth, maxSearchPaneWidth)
+		detailsW := width / 3
+		resultsW := width - searchW - detailsW
+		l.panes[screenSearch] = paneRect{x: 0, y: 0, width: searchW, height: avail}
+		l.panes[screenResults] = paneRect{x: searchW, y: 0, width: resultsW, height: avail}
+		l.panes[screenFlightDetails] = paneRect{x: searchW + resultsW, y: 0, width: detailsW, height: avail}
+	case layoutSplit:
+		bottomH := max(avail/2, searchPaneHeight)
+		topH := avail - bottomH
+		if topH < minPaneHeight {
+			return placePanes(width, height, focused, true)
+		}
+		searchW := clamp(width/3, minSearchPaneWidth, maxSearchPaneWidth)
+		l.panes[screenResults] = paneRect{x: 0, y: 0, width: width, height: topH}
+		l.panes[screenSearch] = paneRect{x: 0, y: topH, width: searchW, height: bottomH}
+		l.panes[screenFlightDetails] = paneRect{x: searchW, y: topH, width: width - searchW, height: bottomH}
+	case layoutStacked:
+		rest := avail - searchPaneHeight
+		if rest < 2*minPaneHeight {
+			return placePanes(width, height, focused, true)
+		}
+		resultsH := rest / 2
+		l.panes[screenSearch] = paneRect{x: 0, y: 0, width: width, height: searchPaneHeight}
+		l.panes[screenResults] = paneRect{x: 0, y: searchPaneHeight, width: width, height: resultsH}
+		l.panes[screenFlightDetails] = paneRect{x: 0, y: searchPaneHeight + resultsH, width: width, height: rest - resultsH}
+	case layoutMaximized:
+		l.panes[focused] = paneRect{x: 0, y: 0, width: width, height: avail}
+	}
+
+	return l
+}
+
+// renderPane draws content inside a bordered box of the given outer size,
+// clipping anything that does not fit.
+func renderPane(content string, r paneRect, focused bool) string {
+	if !r.visible() {
+		return ""
+	}
+	border := styles.Active().Border
+	if focused {
+		border = styles.Active().Accent
+	}
+	clipped := lipgloss.NewStyle().
+		MaxWidth(r.innerWidth()).
+		MaxHeight(r.innerHeight()).
+		Render(content)
+	return lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(border).
+		Width(r.innerWidth()).
+		Height(r.innerHeight()).
+		Render(clipped)
+}
+
+// formLayout builds a form line by line and records where each input is
+// drawn, relative to the pane content, so a click can be mapped back to the
+// input under it.
+type formLayout struct {
+	lines  []string
+	fields []formField
+}
+
+// formField is where an input was drawn. A width of 0 runs to the end of the
+// line.
+type formField struct {
+	input, line, x, width int
+}
+
+// formInput is an input's index in the form and its rendered view.
+type formInput struct {
+	index int
+	view  string
+}
+
+// add appends text, which may span several lines.
+func (f *formLayout) add(text string) {
+	f.lines = append(f.lines, strings.Split(text, "\n")...)
+}
+
+// addInputs appends one line with the inputs side by side, sep apart. The gap
+// after an input counts as part of it, and the last one takes the rest of
+// the line.
+func (f *formLayout) addInputs(sep string, inputs ...formInput) {
+	line := len(f.lines)
+	x := 0
+	views := make([]string, len(inputs))
+	for i, in := range inputs {
+		views[i] = in.view
+		width := 0
+		if i < len(inputs)-1 {
+			width = lipgloss.Width(in.view) + lipgloss.Width(sep)
+		}
+		f.fields = append(f.fields, formField{input: in.index, line: line, x: x, width: width})
+		x += width
+	}
+	f.lines = append(f.lines, strings.Join(views, sep))
+}
+
+func (f formLayout) String() string { return strings.Join(f.lines, "\n") }
+
+// fieldAt is the input drawn at x, y, if any.
+func (f formLayout) fieldAt(x, y int) (int, bool) {
+	for _, field := range f.fields {
+		if y == field.line && x >= field.x && (field.width == 0 || x < field.x+field.width) {
+			return field.input, true
+		}
+	}
+	return 0, false
+}
+
+func clamp(v, lo, hi int) int {
+	return max(lo, min(v, hi))
+}
@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestFormLayoutFieldAt(t *testing.T) {
+	var f formLayout
+	f.add("[Flight Search]")
+	f.add("")
+	f.add("From  To")
+	f.addInputs(" ", formInput{0, "> YYZ     "}, formInput{1, "> CPH"})
+	f.add("Depart\nwrapped label")
+	f.addInputs(" ", formInput{2, "> 2026-11-01"})
+
+	tests := []struct {
+		name  string
+		x, y  int
+		input int
+		ok    bool
+	}{
+		{"first input", 0, 3, 0, true},
+		{"gap after the first input", 10, 3, 0, true},
+		{"second input", 11, 3, 1, true},
+		{"past the end of the line", 40, 3, 1, true},
+		{"below a wrapped label", 5, 6, 2, true},
+		{"label line", 0, 2, 0, false},
+		{"title", 0, 0, 0, false},
+	}
+	for _, tt := range tests {
+		input, ok := f.fieldAt(tt.x, tt.y)
+		if ok != tt.ok || ok && input != tt.input {
+			t.Errorf("%s: fieldAt(%d, %d) = %d, %v, want %d, %v", tt.name, tt.x, tt.y, input, ok, tt.input, tt.ok)
+		}
+	}
+	if want := "[Flight Search]\n\nFrom  To\n> YYZ      > CPH\nDepart\nwrapped label\n> 2026-11-01"; f.String() != want {
+		t.Errorf("String() = %q, want %q", f.String(), want)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/justinm35/flyctl/types"
+	"github.com/spf13/viper"
+)
+
+// LayoverRules decide which connections get a warning:
+//
+//	layovers:
+//	  min_connection: 1h # below this a connection is tight
+//	  airports: {JFK: 1h30m, LHR: 1h30m} # per airport, over the built-in ones
+//	  long: 6h # from this a layover is long
+type LayoverRules struct {
+	MinConnection time.Duration            `mapstructure:"min_connection"`
+	Airports      map[string]time.Duration `mapstructure:"airports"`
+	Long          time.Duration            `mapstructure:"long"`
+}
+
+// defaultMinConnections are minimum connection times for airports known
+// to need more than the default, usually for terminal changes or
+// pre-clearance.
+var defaultMinConnections = map[string]time.Duration{
+	"CDG": 90 * time.Minute,
+	"FRA": 75 * time.Minute,
+	"IST": 90 * time.Minute,
+	"JFK": 90 * time.Minute,
+	"LAX": 90 * time.Minute,
+	"LHR": 90 * time.Minute,
+	"YUL": 75 * time.Minute,
+	"YVR": 75 * time.Minute,
+	"YYZ": 75 * time.Minute,
+}
+
+var layoverRules = LayoverRules{MinConnection: time.Hour, Long: 6 * time.Hour}
+
+// applyLayoverRules reads "layovers" from the config.
+func applyLayoverRules() {
+	var rules LayoverRules
+	if err := viper.UnmarshalKey("layovers", &rules); err != nil {
+		slog.Error("decode layovers", "err", err)
+		return
+	}
+	layoverRules = rules
+}
+
+func (r LayoverRules) validate() []configProblem {
+	var problems []configProblem
+	if r.MinConnection < 0 {
+		problems = append(problems, configProblem{"layovers.min_connection", "can't be negative"})
+	}
+	if r.Long <= r.MinConnection {
+		problems = append(problems, configProblem{"layovers.long", fmt.Sprintf("must be longer than min_connection (%s)", formatDuration(r.MinConnection))})
+	}
+	for code, d := range r.Airports {
+		if !airportCode.MatchString(strings.ToUpper(code)) {
+			problems = append(problems, configProblem{"layovers.airports." + code, "must be keyed by a three-letter IATA airport code like YYZ"})
+		} else if d < 0 {
+			problems = append(problems, configProblem{"layovers.airports." + code, "can't be negative"})
+		}
+	}
+	return problems
+}
+
+// minConnection is the shortest workable connection at an airport.
+func (r LayoverRules) minConnection(airport string) time.Duration {
+	for code, d := range r.Airports {
+		// viper lowercases keys
+		if strings.EqualFold(code, airport) {
+			return d
+		}
+	}
+	if d, ok := defaultMinConnections[airport]; ok {
+		return max(d, r.MinConnection)
+	}
+	return r.MinConnection
+}
+
+// connectionWarning is one thing wrong with a connection.
+type connectionWarning struct {
+	short   string // for the results column, e.g. "tight"
+	message string // for Details
+}
+
+// connectionWarnings checks the connection from arriving on prev to leaving
+// on next.
+func (r LayoverRules) connectionWarnings(prev, next types.Segment) []connectionWarning {
+	var warnings []connectionWarning
+	layover := next.DepartAt.Sub(prev.ArriveAt)
+
+	if prev.To != next.From {
+		warnings = append(warnings, connectionWarning{"airport change",
+			fmt.Sprintf("Change airports: arrive %s, depart %s", prev.To, next.From)})
+	}
+	if next.SelfTransfer {
+		warnings = append(warnings, connectionWarning{"self-transfer",
+			fmt.Sprintf("Self-transfer: separate tickets, collect bags and check in again at %s", next.From)})
+	}
+	if mct := max(r.minConnection(prev.To), r.minConnection(next.From)); layover < mct {
+		warnings = append(warnings, connectionWarning{"tight",
+			fmt.Sprintf("Tight connection: %s at %s, where %s is the minimum", formatDuration(layover), prev.To, formatDuration(mct))})
+	}
+	if overnight(prev.ArriveAt, next.DepartAt) {
+		warnings = append(warnings, connectionWarning{"overnight",
+			fmt.Sprintf("Overnight layover at %s", prev.To)})
+	}
+	if r.Long > 0 && layover >= r.Long {
+		warnings = append(warnings, connectionWarning{"long",
+			fmt.Sprintf("Long layover: %s at %s", formatDuration(layover), prev.To)})
+	}
+	return warnings
+}
+
+// overnight reports whether a layover runs through 2am at the airport.
+// Providers give times on the local clock, so the clock is read as is.
+func overnight(arrive, depart time.Time) bool {
+	night := time.Date(arrive.Year(), arrive.Month(), arrive.Day(), 2, 0, 0, 0, arrive.Location())
+	if night.Before(arrive) {
+		night = night.AddDate(0, 0, 1)
+	}
+	return night.Before(depart)
+}
+
+// stayThreshold separates layovers from stays at the destination of a
+// round trip.
+const stayThreshold = 24 * time.Hour
+
+// offerWarnings checks every connection of an offer. The result is indexed
+// like the segments: warnings[i] is about the connection into segment i.
+func (r LayoverRules) offerWarnings(offer types.FlightOffer) [][]connectionWarning {
+	warnings := make([][]connectionWarning, len(offer.Segments))
+	for i := 1; i < len(offer.Segments); i++ {
+		prev, next := offer.Segments[i-1], offer.Segments[i]
+		if next.DepartAt.Sub(prev.ArriveAt) >= stayThreshold {
+			continue
+		}
+		warnings[i] = r.connectionWarnings(prev, next)
+	}
+	return warnings
+}
+
+// connectionSummary lists the kinds of warning an offer's connections get,
+// e.g. "⚠ tight, overnight", or "" when there are none.
+func (r LayoverRules) connectionSummary(offer types.FlightOffer) string {
+	var kinds []string
+	for _, ws := range r.offerWarnings(offer) {
+		for _, w := range ws {
+			if !slices.Contains(kinds, w.short) {
+				kinds = append(kinds, w.short)
+			}
+		}
+	}
+	if len(kinds) == 0 {
+		return ""
+	}
+	return "⚠ " + strings.Join(kinds, ", ")
+}
+
+// connectionWarningCount is how many warnings an offer's connections get,
+// for sorting.
+func (r LayoverRules) connectionWarningCount(offer types.FlightOffer) int {
+	n := 0
+	for _, ws := range r.offerWarnings(offer) {
+		n += len(ws)
+	}
+	return n
+}
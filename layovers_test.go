@@ -0,0 +1,114 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/justinm35/flyctl/types"
+)
+
+func TestOvernight(t *testing.T) {
+	at := func(day, hour, min int) time.Time { return time.Date(2026, 11, day, hour, min, 0, 0, time.UTC) }
+	tests := []struct {
+		name           string
+		arrive, depart time.Time
+		want           bool
+	}{
+		{"through the night", at(1, 22, 0), at(2, 7, 0), true},
+		{"leaves before 2am", at(1, 22, 0), at(2, 1, 59), false},
+		{"leaves at 2am", at(1, 22, 0), at(2, 2, 0), false},
+		{"leaves just after 2am", at(1, 22, 0), at(2, 2, 1), true},
+		{"arrives after midnight", at(2, 0, 30), at(2, 5, 0), true},
+		{"arrives at 2am", at(2, 2, 0), at(2, 6, 0), true},
+		{"arrives just after 2am", at(2, 2, 1), at(2, 6, 0), false},
+		{"daytime", at(1, 10, 0), at(1, 20, 0), false},
+		{"two nights", at(1, 22, 0), at(3, 7, 0), true},
+	}
+	for _, tt := range tests {
+		if got := overnight(tt.arrive, tt.depart); got != tt.want {
+			t.Errorf("%s: overnight(%s, %s) = %v, want %v", tt.name, tt.arrive.Format(time.DateTime), tt.depart.Format(time.DateTime), got, tt.want)
+		}
+	}
+}
+
+func TestMinConnection(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   LayoverRules
+		airport string
+		want    time.Duration
+	}{
+		{"default", LayoverRules{MinConnection: time.Hour}, "YXU", time.Hour},
+		{"built-in airport", LayoverRules{MinConnection: time.Hour}, "LHR", 90 * time.Minute},
+		{"built-in below the default", LayoverRules{MinConnection: 2 * time.Hour}, "LHR", 2 * time.Hour},
+		{
+			// viper lowercases the keys
+			"override", LayoverRules{MinConnection: time.Hour, Airports: map[string]time.Duration{"jfk": 2 * time.Hour}},
+			"JFK", 2 * time.Hour,
+		},
+		{
+			"override below the built-in and the default",
+			LayoverRules{MinConnection: time.Hour, Airports: map[string]time.Duration{"lhr": 45 * time.Minute}},
+			"LHR", 45 * time.Minute,
+		},
+		{
+			"override for another airport",
+			LayoverRules{MinConnection: time.Hour, Airports: map[string]time.Duration{"jfk": 2 * time.Hour}},
+			"YYZ", 75 * time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.rules.minConnection(tt.airport); got != tt.want {
+			t.Errorf("%s: minConnection(%s) = %s, want %s", tt.name, tt.airport, got, tt.want)
+		}
+	}
+}
+
+func TestOfferWarnings(t *testing.T) {
+	rules := LayoverRules{MinConnection: time.Hour, Long: 6 * time.Hour}
+	arrive := time.Date(2026, 11, 1, 10, 0, 0, 0, time.UTC)
+	offer := func(layover time.Duration, next types.Segment) types.FlightOffer {
+		next.DepartAt = arrive.Add(layover)
+		next.ArriveAt = next.DepartAt.Add(2 * time.Hour)
+		return types.FlightOffer{Segments: []types.Segment{
+			{From: "YXU", To: "YYZ", DepartAt: arrive.Add(-time.Hour), ArriveAt: arrive},
+			next,
+		}}
+	}
+	toCPH := types.Segment{From: "YYZ", To: "CPH"}
+
+	tests := []struct {
+		name  string
+		offer types.FlightOffer
+		want  []string
+	}{
+		{"workable", offer(2*time.Hour, toCPH), nil},
+		{"tight at YYZ", offer(time.Hour, toCPH), []string{"tight"}},
+		{"long", offer(6*time.Hour, toCPH), []string{"long"}},
+		{"just under a day", offer(stayThreshold-time.Minute, toCPH), []string{"overnight", "long"}},
+		{"a day is a stay", offer(stayThreshold, toCPH), nil},
+		{"airport change", offer(3*time.Hour, types.Segment{From: "YTZ", To: "CPH"}), []string{"airport change"}},
+		{"self-transfer", offer(3*time.Hour, types.Segment{From: "YYZ", To: "CPH", SelfTransfer: true}), []string{"self-transfer"}},
+	}
+	for _, tt := range tests {
+		warnings := rules.offerWarnings(tt.offer)
+		if len(warnings) != len(tt.offer.Segments) {
+			t.Fatalf("%s: %d warning lists for %d segments", tt.name, len(warnings), len(tt.offer.Segments))
+		}
+		if warnings[0] != nil {
+			t.Errorf("%s: warnings for the first segment: %v", tt.name, warnings[0])
+		}
+		var got []string
+		for _, w := range warnings[1] {
+			got = append(got, w.short)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: warnings %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if got := rules.offerWarnings(types.FlightOffer{Segments: []types.Segment{{From: "YYZ", To: "CPH"}}}); len(got) != 1 || got[0] != nil {
+		t.Errorf("nonstop: warnings %v, want none", got)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/justinm35/flyctl/styles"
+)
+
+// logPaneHeight is the outer height of the log pane, borders included.
+const logPaneHeight = 10
+
+// logRefreshInterval is how often an open log pane redraws. Log entries
+// don't come in as messages, so the pane polls while it is open.
+const logRefreshInterval = 500 * time.Millisecond
+
+// logTickMsg redraws the log pane. Every opening starts a new chain of
+// ticks with its own id, so ticks still pending from an earlier opening are
+// dropped instead of adding up.
+type logTickMsg struct{ id int }
+
+func logTick(id int) tea.Cmd {
+	return tea.Tick(logRefreshInterval, func(time.Time) tea.Msg { return logTickMsg{id: id} })
+}
+
+// toggleLogs shows or hides the log pane.
+func (m *Model) toggleLogs() tea.Cmd {
+	m.showLogs = !m.showLogs
+	m.applyLayout()
+	if m.showLogs {
+		m.logTickID++
+		return logTick(m.logTickID)
+	}
+	return nil
+}
+
+// viewLogs tails the latest log entries, newest at the bottom.
+func viewLogs(m Model) string {
+	theme := styles.Active()
+	r := m.layout.logs
+	lines := []string{theme.Title().Render("[Log]")}
+
+	muted := lipgloss.NewStyle().Foreground(theme.Muted)
+	entries := recentLogs.tail(max(r.innerHeight()-1, 0))
+	if len(entries) == 0 {
+		lines = append(lines, muted.Render("Nothing logged yet."))
+	}
+	for _, e := range entries {
+		level := lipgloss.NewStyle().Width(5)
+		switch {
+		case e.level >= slog.LevelError:
+			level = level.Foreground(theme.Danger)
+		case e.level >= slog.LevelWarn:
+			level = level.Foreground(theme.Warning)
+		case e.level >= slog.LevelInfo:
+			level = level.Foreground(theme.Info)
+		default:
+			level = level.Foreground(theme.Muted)
+		}
+		line := muted.Render(e.time.Format("15:04:05")) + " " + level.Render(e.level.String()) + " " + e.message
+		if e.attrs != "" {
+			line += " " + muted.Render(e.attrs)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
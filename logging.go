@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justinm35/flyctl/providers/transport"
+)
+
+// logRingSize is how many entries the log pane can show.
+const logRingSize = 200
+
+// recentLogs keeps the latest log entries for the log pane.
+var recentLogs = &logRing{}
+
+// setupLogging sends the log to path, or to flyctl.log in the state
+// directory when path is empty, and to the in-app log pane. --debug lowers
+// the level from info to debug. If the log file can't be opened, only the
+// log pane gets entries rather than flyctl failing.
+func setupLogging(path string, debug bool) {
+	level := slog.LevelInfo
+	if debug {
+		level = slog.LevelDebug
+	}
+	handlers := teeHandler{&ringHandler{ring: recentLogs, level: level}}
+
+	out, err := openLogFile(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "flyctl: log file disabled:", err)
+	} else {
+		handlers = append(handlers, slog.NewTextHandler(out, &slog.HandlerOptions{Level: level, AddSource: debug}))
+	}
+	slog.SetDefault(slog.New(handlers))
+}
+
+func openLogFile(path string) (io.Writer, error) {
+	if path == "" {
+		dir, err := stateDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(dir, "flyctl.log")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+}
+
+// setupHTTPTrace appends provider traffic to path, or to http-trace.jsonl in
+// the state directory when path is empty.
+func setupHTTPTrace(path string, withBodies bool) error {
+	if path == "" {
+		dir, err := stateDir()
+		if err != nil {
+			return err
+		}
+		path = filepath.Join(dir, "http-trace.jsonl")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	transport.EnableTracing(f, withBodies)
+	slog.Info("tracing provider HTTP traffic", "file", path, "bodies", withBodies)
+	return nil
+}
+
+// teeHandler sends every record to all of its handlers.
+type teeHandler []slog.Handler
+
+func (t teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range t {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var firstErr error
+	for _, h := range t {
+		if !h.Enabled(ctx, r.Level) {
+			continue
+		}
+		if err := h.Handle(ctx, r.Clone()); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (t teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(teeHandler, len(t))
+	for i, h := range t {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (t teeHandler) WithGroup(name string) slog.Handler {
+	out := make(teeHandler, len(t))
+	for i, h := range t {
+		out[i] = h.WithGroup(name)
+	}
+	return out
+}
+
+// logEntry is one formatted record in the log pane.
+type logEntry struct {
+	time    time.Time
+	level   slog.Level
+	message string
+	attrs   string // "key=value key=value"
+}
+
+// logRing is a fixed-size buffer of the latest entries.
+type logRing struct {
+	mu      sync.Mutex
+	entries []logEntry
+	next    int
+}
+
+func (r *logRing) add(e logEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.entries) < logRingSize {
+		r.entries = append(r.entries, e)
+	} else {
+		r.entries[r.next] = e
+	}
+	r.next = (r.next + 1) % logRingSize
+}
+
+// tail returns up to n of the latest entries, oldest first.
+func (r *logRing) tail(n int) []logEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ordered := r.entries
+	if len(r.entries) == logRingSize {
+		ordered = append(r.entries[r.next:len(r.entries):len(r.entries)], r.entries[:r.next]...)
+	}
+	if n < len(ordered) {
+		ordered = ordered[len(ordered)-n:]
+	}
+	return append([]logEntry(nil), ordered...)
+}
+
+// ringHandler formats records for the log pane.
+type ringHandler struct {
+	ring   *logRing
+	level  slog.Leveler
+	attrs  string
+	prefix string // open groups, e.g. "req."
+}
+
+func (h *ringHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *ringHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		writeAttr(&b, h.prefix, a)
+		return true
+	})
+	h.ring.add(logEntry{time: r.Time, level: r.Level, message: r.Message, attrs: strings.TrimSpace(b.String())})
+	return nil
+}
+
+func (h *ringHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	b.WriteString(h.attrs)
+	for _, a := range attrs {
+		writeAttr(&b, h.prefix, a)
+	}
+	out := *h
+	out.attrs = b.String()
+	return &out
+}
+
+func (h *ringHandler) WithGroup(name string) slog.Handler {
+	out := *h
+	out.prefix += name + "."
+	return &out
+}
+
+func writeAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		for _, ga := range a.Value.Group() {
+			writeAttr(b, prefix+a.Key+".", ga)
+		}
+		return
+	}
+	value := a.Value.String()
+	if a.Value.Kind() == slog.KindDuration {
+		value = a.Value.Duration().Round(time.Millisecond).String()
+	}
+	if strings.ContainsAny(value, " =\"") {
+		value = fmt.Sprintf("%q", value)
+	}
+	fmt.Fprintf(b, " %s%s=%s", prefix, a.Key, value)
+}
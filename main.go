@@ -1,22 +1,39 @@
 package main
 
 import (
-	"log"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/justinm35/flyctl/styles"
 	"github.com/justinm35/flyctl/types"
+	"github.com/spf13/viper"
 )
 
 type screen int
 
+const statusTimeout = 4 * time.Second
+
 const (
 	screenSearch screen = iota
 	screenResults
 	screenFlightDetails
 	screenCount
+
+	// screenCompare replaces all panes while open; it is not part of the
+	// pane focus cycle.
+	screenCompare = screenCount
+	screenHistory = screenCount + 1
+	screenSetup   = screenCount + 2
 )
 
 var allScreens = []screen{
@@ -26,11 +43,83 @@ var allScreens = []screen{
 }
 
 func main() {
-	InitConfig()
+	configPath := flag.String("config", "", "config file (default $XDG_CONFIG_HOME/flyctl/config.yaml)")
+	logFile := flag.String("log-file", "", "log file (default $XDG_STATE_HOME/flyctl/flyctl.log)")
+	debug := flag.Bool("debug", false, "log debug output")
+	profile := flag.String("profile", "", `profile from config.yaml to use (default "profile" in the config)`)
+	traceHTTP := flag.Bool("trace-http", false, "record provider requests and responses, with credentials redacted")
+	traceFile := flag.String("trace-file", "", "trace file for --trace-http (default $XDG_STATE_HOME/flyctl/http-trace.jsonl)")
+	traceBodies := flag.Bool("trace-bodies", false, "include request and response bodies in the trace")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: flyctl [flags] [search [search flags] | config <command>]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	setupLogging(*logFile, *debug)
+	if *traceHTTP {
+		if err := setupHTTPTrace(*traceFile, *traceBodies); err != nil {
+			fmt.Fprintln(os.Stderr, "flyctl: --trace-http:", err)
+			os.Exit(1)
+		}
+	}
+	configErr := InitConfig(*configPath)
+	if *profile != "" {
+		useProfile(*profile)
+	}
+	if args := flag.Args(); len(args) > 0 && args[0] == "config" {
+		if err := runConfigCommand(args[1:], *configPath, configErr); err != nil {
+			fmt.Fprintln(os.Stderr, "flyctl config:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if configErr != nil {
+		fmt.Fprintln(os.Stderr, "flyctl:", configErr)
+		os.Exit(1)
+	}
+	if _, problems := loadConfig(viper.GetViper()); len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "flyctl: invalid config in %s:\n", viper.ConfigFileUsed())
+		for _, p := range problems {
+			fmt.Fprintln(os.Stderr, "  "+p.String())
+		}
+		fmt.Fprintln(os.Stderr, "fix it with `flyctl config edit` or `flyctl config set <key> <value>`")
+		os.Exit(1)
+	}
+	theme, err := loadTheme()
+	if err != nil {
+		slog.Error("load theme", "err", err)
+	}
+	styles.SetActive(theme)
+	applyLocale()
+	applyLayoverRules()
+
+	switch args := flag.Args(); {
+	case len(args) == 0:
+	case args[0] == "search":
+		if err := runSearchCommand(args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, "flyctl search:", err)
+			os.Exit(1)
+		}
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "flyctl: unknown command %q\n", args[0])
+		flag.Usage()
+		os.Exit(2)
+	}
+
 	m := NewModel()
-	_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
+	if stop, err := watchConfig(viper.ConfigFileUsed(), p.Send); err != nil {
+		slog.Warn("config changes will need a restart", "err", err)
+	} else {
+		defer stop()
+	}
+	_, err = p.Run()
 	if err != nil {
-		log.Fatal(err)
+		slog.Error("run TUI", "err", err)
+		fmt.Fprintln(os.Stderr, "flyctl:", err)
+		os.Exit(1)
 	}
 }
 
@@ -43,23 +132,65 @@ type Model struct {
 	screenFlightDetails FlightDetailsState
 	width               int
 	height              int
+	maximized           bool
+	layout              layout
+	keys                keyMap
+	help                help.Model
+	showHelp            bool
+	showLogs            bool
+	logTickID           int
+	palette             PaletteState
+	compare             CompareState
+	history             HistoryState
+	setup               SetupState
+	status              string
+	statusID            int
+	tabs                []searchTab
+	activeTab           int
+	nextTabID           int
 }
 
-type searchResultsMsg struct{ offers []types.FlightOffer }
+type searchResultsMsg struct {
+	tabID  int
+	query  searchQuery
+	offers []types.FlightOffer
+}
 type flightDetailsSelectedMsg struct{ offer types.FlightOffer }
 type newStarredRowMsg struct{ formattedRows []table.Row }
 
-type errMsg struct{ err error }
+type errMsg struct {
+	tabID int
+	err   error
+}
+
+// clearStatusMsg expires the bottom bar status set with the matching id.
+type clearStatusMsg struct{ id int }
 
 // NewModel: Initial model
 func NewModel() Model {
-	return Model{
-		focusedPane:         0,
-		screen:              screenSearch,
-		screenSearch:        newSearchState(),
-		screenResults:       newResultsState(),
-		screenFlightDetails: newFlightDetailsState(),
+	keys := loadKeyMap()
+	m := Model{
+		focusedPane: 0,
+		screen:      screenSearch,
+		keys:        keys,
+		help:        help.New(),
+		palette:     newPaletteState(),
+		compare:     newCompareState(keys.Compare),
+		history:     newHistoryState(keys.History),
+		setup:       newSetupState(),
+	}
+	m.newTab()
+
+	// pick up where the last session left off
+	if err := m.restoreLatestSearch(); err != nil {
+		slog.Error("restore latest search", "err", err)
 	}
+	m.applyTheme()
+
+	if len(unsetCredentials(viper.GetViper(), m.screenSearch.provider)) > 0 {
+		m.openSetup()
+	}
+	return m
 }
 
 // Init: Kick off the event loop
@@ -69,51 +200,126 @@ func (m Model) Init() tea.Cmd {
 
 // Update: handle Msgs
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	if km, ok := msg.(tea.KeyMsg); ok {
-		if km.String() == "tab" {
-			if m.focusedPane == int(screenCount)-1 {
-				m.focusedPane = 0
-			} else {
-				m.focusedPane += 1
-				return m, nil
-			}
-		}
-		if km.String() == "shift+tab" {
-			if m.focusedPane == 0 {
-				m.focusedPane = int(screenCount) - 1
-			} else {
-				m.focusedPane -= 1
-				return m, nil
-			}
+	if mm, ok := msg.(tea.MouseMsg); ok {
+		return updateMouse(m, mm)
+	}
 
-		}
-		if km.String() == "ctrl+z" {
-			return m, tea.Suspend
-		}
-		if km.String() == "ctrl+c" {
+	// text typed into a focused input reaches it before any global binding,
+	// so e.g. "?" can be part of a secret
+	if km, ok := msg.(tea.KeyMsg); ok && !(m.typing() && isText(km)) {
+		switch {
+		case key.Matches(km, m.keys.Global.Quit):
 			return m, tea.Quit
+		case m.palette.open:
+			return updatePalette(m, km)
+		case key.Matches(km, m.keys.Global.Palette):
+			m.showHelp = false
+			return m, m.palette.show(m)
+		case key.Matches(km, m.keys.Global.Help):
+			m.showHelp = !m.showHelp
+			return m, nil
+		case m.showHelp:
+			if km.Type == tea.KeyEsc {
+				m.showHelp = false
+			}
+			return m, nil
+		case key.Matches(km, m.keys.Global.NextPane):
+			m.focusedPane = (m.focusedPane + 1) % int(screenCount)
+			m.applyLayout()
+			return m, nil
+		case key.Matches(km, m.keys.Global.PrevPane):
+			m.focusedPane = (m.focusedPane + int(screenCount) - 1) % int(screenCount)
+			m.applyLayout()
+			return m, nil
+		case key.Matches(km, m.keys.Global.NewTab):
+			return m, m.newTab()
+		case key.Matches(km, m.keys.Global.CloseTab):
+			return m, m.closeTab()
+		case key.Matches(km, m.keys.Global.NextTab):
+			return m, m.switchTab(m.activeTab + 1)
+		case key.Matches(km, m.keys.Global.PrevTab):
+			return m, m.switchTab(m.activeTab - 1)
+		case key.Matches(km, m.keys.Global.Logs):
+			return m, m.toggleLogs()
+		case key.Matches(km, m.keys.Global.History):
+			return m, m.openHistory()
+		case key.Matches(km, m.keys.Global.Maximize):
+			m.maximized = !m.maximized
+			m.applyLayout()
+			return m, nil
+		case key.Matches(km, m.keys.Global.Suspend):
+			return m, tea.Suspend
 		}
 	}
 
 	switch msg := msg.(type) {
+	case logTickMsg:
+		if m.showLogs && msg.id == m.logTickID {
+			return m, logTick(msg.id)
+		}
+		return m, nil
+	case configChangedMsg:
+		return m, m.applyConfigChange()
+	case clearStatusMsg:
+		if msg.id == m.statusID {
+			m.status = ""
+		}
+		return m, nil
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		m.screenResults.setTableWidth(m.width)
+		m.applyLayout()
+	case spinner.TickMsg:
+		if m.screenSearch.loading {
+			var cmd tea.Cmd
+			m.screenSearch.spinner, cmd = m.screenSearch.spinner.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+	case errMsg:
+		if msg.tabID != m.activeTabID() {
+			if i, ok := m.tabIndex(msg.tabID); ok {
+				m.tabs[i].search.loading = false
+				m.tabs[i].search.err = msg.err.Error()
+			}
+			return m, nil
+		}
+		m.screenSearch.loading = false
+		m.screenSearch.err = msg.err.Error()
+		return m, nil
 	case searchResultsMsg:
+		if err := recordSearch(msg.query, msg.offers, time.Now()); err != nil {
+			slog.Error("record search", "err", err)
+		}
+		if msg.tabID != m.activeTabID() {
+			i, ok := m.tabIndex(msg.tabID)
+			if !ok {
+				return m, nil
+			}
+			m.tabs[i].search.loading = false
+			m.tabs[i].results.setOffers(msg.offers)
+			return m, m.setStatus(fmt.Sprintf("results ready in tab %d", i+1))
+		}
 		m.screenSearch.loading = false
 		m.focusedPane = 1
-		m.screenResults.offers = msg.offers
-		// Store the data here
-		StoreData("allOffers", msg.offers)
-		m.screenResults.buildTable(m.width)
+		m.screenResults.setOffers(msg.offers)
 		m.screen = screenResults
+		m.applyLayout()
 		return m, nil
 	case flightDetailsSelectedMsg:
 		m.screenFlightDetails.initFlightDetails(msg.offer)
 		m.screen = screenFlightDetails
 	}
 
+	switch m.screen {
+	case screenCompare:
+		return updateCompare(m, msg)
+	case screenHistory:
+		return updateHistory(m, msg)
+	case screenSetup:
+		return updateSetup(m, msg)
+	}
+
 	switch allScreens[m.focusedPane] {
 	case screenSearch:
 		return updateSearch(m, msg)
@@ -126,72 +332,166 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 }
 
+// focusPane moves focus to the given pane.
+func (m *Model) focusPane(s screen) {
+	m.focusedPane = int(s)
+	m.applyLayout()
+}
+
+// typing reports whether a text input has the focus: a field of the setup
+// wizard or the search form, the name of a search being saved, or a list
+// filter.
+func (m Model) typing() bool {
+	if m.palette.open || m.showHelp {
+		return false
+	}
+	switch m.screen {
+	case screenSetup:
+		return m.setup.focus > 0 && m.setup.inputs[m.setup.focus-1].Focused()
+	case screenHistory:
+		return m.history.list.FilterState() == list.Filtering
+	case screenCompare:
+		return false
+	}
+	if allScreens[m.focusedPane] != screenSearch {
+		return false
+	}
+	search := m.screenSearch
+	switch search.mode {
+	case searchBrowsing:
+		return search.saved.FilterState() == list.Filtering
+	case searchNaming:
+		return search.nameInput.Focused()
+	default:
+		return search.inputs[search.focus].Focused()
+	}
+}
+
+// isText reports whether a key press types text rather than being a
+// shortcut.
+func isText(km tea.KeyMsg) bool {
+	return km.Type == tea.KeyRunes && !km.Alt || km.Type == tea.KeySpace
+}
+
+// setStatus shows a short message in the bottom bar for a few seconds.
+func (m *Model) setStatus(text string) tea.Cmd {
+	m.statusID++
+	m.status = text
+	id := m.statusID
+	return tea.Tick(statusTimeout, func(time.Time) tea.Msg { return clearStatusMsg{id: id} })
+}
+
+// applyLayout recomputes pane geometry and resizes the components that
+// depend on it.
+func (m *Model) applyLayout() {
+	m.layout = computeLayout(m.width, m.height, allScreens[m.focusedPane], m.maximized, m.showLogs)
+
+	if results := m.layout.pane(screenResults); results.visible() {
+		m.screenResults.resize(results.innerWidth(), results.innerHeight())
+	}
+	if details := m.layout.pane(screenFlightDetails); details.visible() {
+		m.screenFlightDetails.resize(details.innerWidth(), details.innerHeight())
+	}
+	if search := m.layout.pane(screenSearch); search.visible() {
+		m.screenSearch.saved.SetSize(search.innerWidth(), max(search.innerHeight()-4, 1))
+	}
+	switch m.screen {
+	case screenCompare:
+		m.compare.resize(m.layout.full.innerWidth(), m.layout.full.innerHeight())
+	case screenHistory:
+		m.history.resize(m.layout.full.innerWidth(), m.layout.full.innerHeight())
+	}
+}
+
 // View: Return a string based on the state of our model
 func (m Model) View() string {
-	totalHeight := m.height - 2
-	totalWidth := m.width - 4
-
-	thirdWidth := m.width / 3
-
-	// halfWidth := totalWidth / 2
-	halfHeight := m.height / 2
-
-	paneStyle := lipgloss.NewStyle().
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(styles.White)
-
-	// Top pane (results)
-	var topPane string
-	if allScreens[m.focusedPane] == screenResults {
-		topPane = paneStyle.
-			Height(halfHeight - 30).
-			Width(totalWidth + 2).
-			BorderForeground(styles.NeonPurple).
-			Render(viewResults(m))
-	} else {
-		topPane = paneStyle.
-			Height(halfHeight - 30).
-			Width(totalWidth + 2).
-			Render(viewResults(m))
-	}
-
-	// Bottom left (search)
-	var bottomLeftPane string
-	if allScreens[m.focusedPane] == screenSearch {
-		bottomLeftPane = paneStyle.
-			Height(totalHeight - halfHeight).
-			Width(thirdWidth).
-			BorderForeground(styles.NeonPurple).
-			Render(viewSeach(m))
-	} else {
-		bottomLeftPane = paneStyle.
-			Height(totalHeight - halfHeight).
-			Width(thirdWidth).
-			Render(viewSeach(m))
-	}
-
-	// Bottom right (details)
-	var bottomRightPane string
-	if allScreens[m.focusedPane] == screenFlightDetails {
-		bottomRightPane = paneStyle.
-			Height(totalHeight - halfHeight).
-			Width(totalWidth - thirdWidth).
-			BorderForeground(styles.NeonPurple).
-			Render(viewFlightDetails(m))
-	} else {
-		bottomRightPane = paneStyle.
-			Height(totalHeight - halfHeight).
-			Width(totalWidth - thirdWidth).
-			Render(viewFlightDetails(m))
+	focused := allScreens[m.focusedPane]
+	l := m.layout
+
+	searchPane := renderPane(viewSeach(m), l.pane(screenSearch), focused == screenSearch)
+	resultsPane := renderPane(viewResults(m), l.pane(screenResults), focused == screenResults)
+	detailsPane := renderPane(viewFlightDetails(m), l.pane(screenFlightDetails), focused == screenFlightDetails)
+
+	var panes string
+	switch l.mode {
+	case layoutSideBySide:
+		panes = lipgloss.JoinHorizontal(lipgloss.Top, searchPane, resultsPane, detailsPane)
+	case layoutSplit:
+		bottomHalf := lipgloss.JoinHorizontal(lipgloss.Top, searchPane, detailsPane)
+		panes = lipgloss.JoinVertical(lipgloss.Left, resultsPane, bottomHalf)
+	case layoutStacked:
+		panes = lipgloss.JoinVertical(lipgloss.Left, searchPane, resultsPane, detailsPane)
+	case layoutMaximized:
+		panes = map[screen]string{
+			screenSearch:        searchPane,
+			screenResults:       resultsPane,
+			screenFlightDetails: detailsPane,
+		}[focused]
+	}
+
+	switch m.screen {
+	case screenCompare:
+		panes = renderPane(viewCompare(m), l.full, true)
+		focused = screenCompare
+	case screenHistory:
+		panes = renderPane(viewHistory(m), l.full, true)
+		focused = screenHistory
+	case screenSetup:
+		panes = renderPane(viewSetup(m), l.full, true)
+		focused = screenSetup
+	}
+	if m.showHelp {
+		panes = m.viewHelp(focused)
+	}
+	if m.palette.open {
+		panes = m.viewPalette()
 	}
 
+	var status string
+	if m.status != "" {
+		status = lipgloss.NewStyle().Foreground(styles.Active().Info).Render(m.status) + "  "
+	}
+	shortHelp := m.help
+	shortHelp.Width = l.bar.width - lipgloss.Width(status)
 	bottomBar := lipgloss.NewStyle().
-		Foreground(styles.MutedGray).
-		Width(totalWidth).
-		Render("quit: ctrl + c | cycle panes: tab")
+		Width(l.bar.width).
+		MaxWidth(l.bar.width).
+		Render(status + shortHelp.ShortHelpView(m.keys.shortHelp()))
+
+	views := []string{m.viewTabs(), panes}
+	if l.logs.visible() {
+		views = append(views, renderPane(viewLogs(m), l.logs, false))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, append(views, bottomBar)...)
+}
+
+// viewHelp renders the help overlay for the focused pane, centered in the
+// area normally taken by the panes.
+func (m Model) viewHelp(focused screen) string {
+	title := map[screen]string{
+		screenSearch:        "[Search keys]",
+		screenResults:       "[Results keys]",
+		screenFlightDetails: "[Details keys]",
+		screenCompare:       "[Compare keys]",
+		screenHistory:       "[History keys]",
+		screenSetup:         "[Setup keys]",
+	}[focused]
 
-	bottomHalf := lipgloss.JoinHorizontal(lipgloss.Bottom, bottomLeftPane, bottomRightPane)
-	fullView := lipgloss.JoinVertical(lipgloss.Top, topPane, bottomHalf, bottomBar)
+	fullHelp := m.help
+	fullHelp.ShowAll = true
+	body := lipgloss.JoinVertical(
+		lipgloss.Left,
+		styles.Active().Title().Render(title),
+		"",
+		fullHelp.FullHelpView(m.keys.paneHelp(focused)),
+		"",
+		lipgloss.NewStyle().Foreground(styles.Active().Muted).Render("close: ? or esc"),
+	)
+	box := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(styles.Active().Accent).
+		Padding(1, 2).
+		Render(body)
 
-	return fullView
+	return lipgloss.Place(m.width, m.layout.full.height, lipgloss.Center, lipgloss.Center, box)
 }
@@ -0,0 +1,56 @@
+// Package money parses, converts between units and formats types.Money
+// amounts without going through floating point.
+package money
+
+import "strings"
+
+// minorUnits lists the ISO 4217 currencies whose minor unit is not 1/100.
+var minorUnits = map[string]int{
+	// no minor unit
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	// thousandths
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	// ten-thousandths
+	"CLF": 4, "UYW": 4,
+}
+
+// MinorUnits is the number of decimal places of a currency: 2 for most,
+// 0 for JPY, 3 for KWD.
+func MinorUnits(currency string) int {
+	if n, ok := minorUnits[strings.ToUpper(currency)]; ok {
+		return n
+	}
+	return 2
+}
+
+// symbols are the common symbols of currencies. Currencies without one are
+// shown by code.
+var symbols = map[string]string{
+	"AUD": "A$", "BRL": "R$", "CAD": "CA$", "CHF": "CHF", "CNY": "CN¥", "EUR": "€",
+	"GBP": "£", "HKD": "HK$", "ILS": "₪", "INR": "₹", "JPY": "¥", "KRW": "₩",
+	"MXN": "MX$", "NZD": "NZ$", "PHP": "₱", "SGD": "S$", "THB": "฿", "TRY": "₺",
+	"TWD": "NT$", "USD": "$", "VND": "₫",
+}
+
+// localDollars are dollar currencies shown as a bare "$" in their own
+// country, where there is no doubt which dollar is meant.
+var localDollars = map[string]string{
+	"AU": "AUD", "CA": "CAD", "HK": "HKD", "MX": "MXN", "NZ": "NZD", "SG": "SGD", "TW": "TWD",
+}
+
+// Symbol is the symbol for a currency as written in a locale, or the code
+// itself when the currency has none.
+func Symbol(currency string, l Locale) string {
+	currency = strings.ToUpper(currency)
+	if localDollars[l.Region] == currency {
+		return "$"
+	}
+	if currency == "USD" && l.Region != "US" && l.Region != "" && localDollars[l.Region] != "" {
+		return "US$"
+	}
+	if s, ok := symbols[currency]; ok {
+		return s
+	}
+	return currency
+}
@@ -0,0 +1,107 @@
+package money
+
+import (
+	"os"
+	"strings"
+
+	"github.com/justinm35/flyctl/types"
+)
+
+// Locale picks the separators and symbol placement amounts are written
+// with.
+type Locale struct {
+	Language string // ISO 639, e.g. "de"
+	Region   string // ISO 3166, e.g. "CH"; may be empty
+}
+
+type conventions struct {
+	decimal     string
+	group       string
+	symbolAfter bool // "12,50 €" rather than "€12.50"
+	space       bool // a space between symbol and number
+}
+
+var (
+	english  = conventions{decimal: ".", group: ","}
+	european = conventions{decimal: ",", group: ".", symbolAfter: true, space: true}
+	nordic   = conventions{decimal: ",", group: " ", symbolAfter: true, space: true}
+)
+
+var languageConventions = map[string]conventions{
+	"en": english, "ja": english, "ko": english, "zh": english, "th": english, "he": english,
+	"de": european, "es": european, "it": european, "da": european, "id": european, "tr": european,
+	"pt": {decimal: ",", group: ".", space: true},
+	"nl": {decimal: ",", group: ".", space: true},
+	"fr": {decimal: ",", group: " ", symbolAfter: true, space: true},
+	"sv": nordic, "nb": nordic, "fi": nordic, "cs": nordic, "pl": nordic, "ru": nordic, "uk": nordic,
+}
+
+var regionConventions = map[string]conventions{
+	"CH": {decimal: ".", group: "’", space: true},
+	"CA": english, // en_CA and fr_CA differ, but fr_CA is close enough to fr
+}
+
+func (l Locale) conventions() conventions {
+	if c, ok := regionConventions[l.Region]; ok && (l.Region != "CA" || l.Language == "en") {
+		return c
+	}
+	if c, ok := languageConventions[l.Language]; ok {
+		return c
+	}
+	return english
+}
+
+// ParseLocale reads a POSIX locale name such as "de_CH.UTF-8" or a BCP 47
+// tag such as "en-CA". Anything unrecognised, including "C" and "POSIX",
+// is read as English.
+func ParseLocale(name string) Locale {
+	name, _, _ = strings.Cut(name, ".")
+	name, _, _ = strings.Cut(name, "@")
+	lang, region, _ := strings.Cut(strings.ReplaceAll(name, "-", "_"), "_")
+	lang = strings.ToLower(lang)
+	if lang == "" || lang == "c" || lang == "posix" {
+		return Locale{Language: "en"}
+	}
+	return Locale{Language: lang, Region: strings.ToUpper(region)}
+}
+
+// EnvLocale is the locale for monetary amounts from LC_ALL, LC_MONETARY or
+// LANG, the first one set.
+func EnvLocale() Locale {
+	for _, env := range []string{"LC_ALL", "LC_MONETARY", "LANG"} {
+		if v := os.Getenv(env); v != "" {
+			return ParseLocale(v)
+		}
+	}
+	return Locale{Language: "en"}
+}
+
+var current = EnvLocale()
+
+// SetLocale changes the locale Format writes amounts in.
+func SetLocale(l Locale) { current = l }
+
+// Format renders an amount for people in the current locale, e.g.
+// "CA$1,234.50", "1.234,50 €" or "¥1,235".
+func Format(m types.Money) string {
+	return FormatIn(m, current)
+}
+
+// FormatIn is Format in a given locale.
+func FormatIn(m types.Money, l Locale) string {
+	c := l.conventions()
+	number := digits(m, c.decimal, c.group)
+	symbol := Symbol(m.Currency, l)
+	sep := ""
+	if c.space || symbol == strings.ToUpper(m.Currency) {
+		sep = " "
+	}
+
+	if c.symbolAfter {
+		return number + sep + symbol
+	}
+	if sign, rest, ok := strings.Cut(number, "-"); ok && sign == "" {
+		return "-" + symbol + sep + rest
+	}
+	return symbol + sep + number
+}
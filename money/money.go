@@ -0,0 +1,114 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/justinm35/flyctl/types"
+)
+
+var ErrOverflow = errors.New("amount out of range")
+
+// Parse reads a decimal amount such as "1234.5" or "-12" in major units
+// into minor units of currency. Digits beyond the currency's minor unit are
+// rounded half away from zero.
+func Parse(amount, currency string) (types.Money, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	s := strings.TrimSpace(amount)
+	if s == "" {
+		return types.Money{}, fmt.Errorf("empty amount")
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" || !allDigits(whole) || !allDigits(frac) {
+		return types.Money{}, fmt.Errorf("invalid decimal %q", amount)
+	}
+
+	places := MinorUnits(currency)
+	roundUp := false
+	if len(frac) > places {
+		roundUp = frac[places] >= '5'
+		frac = frac[:places]
+	}
+	frac += strings.Repeat("0", places-len(frac))
+
+	var minor int64
+	for _, c := range whole + frac {
+		d := int64(c - '0')
+		if minor > (math.MaxInt64-d)/10 {
+			return types.Money{}, fmt.Errorf("%q: %w", amount, ErrOverflow)
+		}
+		minor = minor*10 + d
+	}
+	if roundUp {
+		if minor == math.MaxInt64 {
+			return types.Money{}, fmt.Errorf("%q: %w", amount, ErrOverflow)
+		}
+		minor++
+	}
+	if negative {
+		minor = -minor
+	}
+	return types.Money{Amount: minor, Currency: currency}, nil
+}
+
+// Decimal renders an amount as a plain decimal in major units, e.g.
+// "1234.50", for files and other programs rather than people.
+func Decimal(m types.Money) string {
+	return digits(m, ".", "")
+}
+
+// digits renders the number part of an amount with the given separators.
+func digits(m types.Money, decimal, group string) string {
+	places := MinorUnits(m.Currency)
+	sign := ""
+	abs := uint64(m.Amount)
+	if m.Amount < 0 {
+		sign = "-"
+		abs = uint64(-(m.Amount + 1)) + 1 // MinInt64 has no positive counterpart
+	}
+	scale := uint64(pow10(places))
+	whole := fmt.Sprint(abs / scale)
+
+	if group != "" {
+		var b strings.Builder
+		for i, c := range whole {
+			if i > 0 && (len(whole)-i)%3 == 0 {
+				b.WriteString(group)
+			}
+			b.WriteRune(c)
+		}
+		whole = b.String()
+	}
+	if places == 0 {
+		return sign + whole
+	}
+	return fmt.Sprintf("%s%s%s%0*d", sign, whole, decimal, places, abs%scale)
+}
+
+func allDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func pow10(n int) int64 {
+	x := int64(1)
+	for range n {
+		x *= 10
+	}
+	return x
+}
@@ -0,0 +1,275 @@
+package money
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/justinm35/flyctl/types"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		amount, currency string
+		want             types.Money
+	}{
+		{"1234.5", "CAD", types.Money{Amount: 123450, Currency: "CAD"}},
+		{"12", "usd", types.Money{Amount: 1200, Currency: "USD"}},
+		{" +0.07 ", "EUR", types.Money{Amount: 7, Currency: "EUR"}},
+		{"-12.34", "EUR", types.Money{Amount: -1234, Currency: "EUR"}},
+		{".5", "GBP", types.Money{Amount: 50, Currency: "GBP"}},
+		{"3.", "GBP", types.Money{Amount: 300, Currency: "GBP"}},
+		// rounded half away from zero to the minor unit
+		{"1.005", "USD", types.Money{Amount: 101, Currency: "USD"}},
+		{"1.0049", "USD", types.Money{Amount: 100, Currency: "USD"}},
+		{"-1.005", "USD", types.Money{Amount: -101, Currency: "USD"}},
+		{"1234.5", "JPY", types.Money{Amount: 1235, Currency: "JPY"}},
+		{"1234.4", "JPY", types.Money{Amount: 1234, Currency: "JPY"}},
+		{"1.2345", "KWD", types.Money{Amount: 1235, Currency: "KWD"}},
+		{"92233720368547758.07", "USD", types.Money{Amount: 9223372036854775807, Currency: "USD"}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.amount, tt.currency)
+		if err != nil {
+			t.Errorf("Parse(%q, %q): %v", tt.amount, tt.currency, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q, %q) = %v, want %v", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		amount   string
+		overflow bool
+	}{
+		{"", false},
+		{"  ", false},
+		{".", false},
+		{"-", false},
+		{"1,234.50", false},
+		{"1.2.3", false},
+		{"12a", false},
+		{"1e3", false},
+		{"--1", false},
+		{"92233720368547758.08", true},
+		{"92233720368547758.075", true},
+		{"100000000000000000000", true},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.amount, "USD")
+		if err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", tt.amount)
+			continue
+		}
+		if got := errors.Is(err, ErrOverflow); got != tt.overflow {
+			t.Errorf("Parse(%q) error %v, overflow = %v, want %v", tt.amount, err, got, tt.overflow)
+		}
+	}
+}
+
+func TestMinorUnits(t *testing.T) {
+	tests := []struct {
+		currency string
+		want     int
+	}{
+		{"CAD", 2},
+		{"EUR", 2},
+		{"jpy", 0},
+		{"KRW", 0},
+		{"KWD", 3},
+		{"CLF", 4},
+		{"XYZ", 2},
+		{"", 2},
+	}
+	for _, tt := range tests {
+		if got := MinorUnits(tt.currency); got != tt.want {
+			t.Errorf("MinorUnits(%q) = %d, want %d", tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	tests := []struct {
+		m    types.Money
+		want string
+	}{
+		{types.Money{Amount: 123450, Currency: "CAD"}, "1234.50"},
+		{types.Money{Amount: 7, Currency: "EUR"}, "0.07"},
+		{types.Money{Amount: -1234, Currency: "EUR"}, "-12.34"},
+		{types.Money{Amount: 1235, Currency: "JPY"}, "1235"},
+		{types.Money{Amount: 1235, Currency: "KWD"}, "1.235"},
+		{types.Money{Amount: -9223372036854775808, Currency: "USD"}, "-92233720368547758.08"},
+	}
+	for _, tt := range tests {
+		if got := Decimal(tt.m); got != tt.want {
+			t.Errorf("Decimal(%v) = %q, want %q", tt.m, got, tt.want)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	rates, err := NewRates("EUR", time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC), map[string]string{
+		"USD": "1.0842",
+		"CAD": "1.4711",
+		"JPY": "162.35",
+		"KWD": "0.3321",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		m    types.Money
+		to   string
+		want types.Money
+		rate string
+	}{
+		{types.Money{Amount: 10000, Currency: "EUR"}, "USD", types.Money{Amount: 10842, Currency: "USD"}, "1.0842"},
+		{types.Money{Amount: 10000, Currency: "EUR"}, "eur", types.Money{Amount: 10000, Currency: "EUR"}, "1"},
+		// 100 USD * 1.4711 / 1.0842 = 135.6853...
+		{types.Money{Amount: 10000, Currency: "USD"}, "CAD", types.Money{Amount: 13569, Currency: "CAD"}, "1.356853"},
+		// to a currency without a minor unit: 1 EUR = 162.35 JPY
+		{types.Money{Amount: 100, Currency: "EUR"}, "JPY", types.Money{Amount: 162, Currency: "JPY"}, "162.35"},
+		{types.Money{Amount: 1, Currency: "EUR"}, "JPY", types.Money{Amount: 2, Currency: "JPY"}, "162.35"},
+		// from one: 16235 JPY = 100 EUR exactly
+		{types.Money{Amount: 16235, Currency: "JPY"}, "EUR", types.Money{Amount: 10000, Currency: "EUR"}, "0.00616"},
+		// to thousandths: 10 EUR = 3.321 KWD
+		{types.Money{Amount: 1000, Currency: "EUR"}, "KWD", types.Money{Amount: 3321, Currency: "KWD"}, "0.3321"},
+		// half a cent rounds away from zero in both directions
+		{types.Money{Amount: 5, Currency: "EUR"}, "USD", types.Money{Amount: 5, Currency: "USD"}, "1.0842"},
+		{types.Money{Amount: -10000, Currency: "EUR"}, "USD", types.Money{Amount: -10842, Currency: "USD"}, "1.0842"},
+	}
+	for _, tt := range tests {
+		got, rate, err := rates.Convert(tt.m, tt.to)
+		if err != nil {
+			t.Errorf("Convert(%v, %s): %v", tt.m, tt.to, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Convert(%v, %s) = %v, want %v", tt.m, tt.to, got, tt.want)
+		}
+		if s := FormatRate(rate); s != tt.rate {
+			t.Errorf("Convert(%v, %s) rate = %s, want %s", tt.m, tt.to, s, tt.rate)
+		}
+	}
+
+	if _, _, err := rates.Convert(types.Money{Amount: 100, Currency: "EUR"}, "XYZ"); err == nil {
+		t.Error("Convert to a currency without a rate succeeded")
+	}
+	_, _, err = rates.Convert(types.Money{Amount: 9223372036854775807, Currency: "EUR"}, "JPY")
+	if !errors.Is(err, ErrOverflow) {
+		t.Errorf("Convert past int64 = %v, want ErrOverflow", err)
+	}
+}
+
+func TestRoundHalfAway(t *testing.T) {
+	tests := []struct {
+		num, denom int64
+		want       int64
+	}{
+		{5, 2, 3},
+		{-5, 2, -3},
+		{7, 3, 2},
+		{-7, 3, -2},
+		{8, 3, 3},
+		{149, 100, 1},
+		{150, 100, 2},
+		{0, 1, 0},
+	}
+	for _, tt := range tests {
+		got, err := roundHalfAway(big.NewRat(tt.num, tt.denom))
+		if err != nil || got != tt.want {
+			t.Errorf("roundHalfAway(%d/%d) = %d, %v, want %d", tt.num, tt.denom, got, err, tt.want)
+		}
+	}
+}
+
+func TestNewRatesErrors(t *testing.T) {
+	for _, value := range []string{"", "abc", "0", "-1.2"} {
+		if _, err := NewRates("EUR", time.Time{}, map[string]string{"USD": value}); err == nil {
+			t.Errorf("NewRates with USD rate %q succeeded", value)
+		}
+	}
+}
+
+func TestParseRates(t *testing.T) {
+	r, err := ParseRates([]byte(`{"base": "EUR", "date": "2026-10-16", "rates": {"USD": 1.0842, "CAD": 1.4711}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Base != "EUR" || !r.Date.Equal(time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("ParseRates base %s date %s", r.Base, r.Date)
+	}
+	rate, err := r.Rate("usd", "CAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// exact, not through float64
+	if want := big.NewRat(14711, 10842); rate.Cmp(want) != 0 {
+		t.Errorf("Rate(USD, CAD) = %s, want %s", rate, want)
+	}
+
+	for _, data := range []string{
+		`not json`,
+		`{"base": "EUR", "date": "2026-10-16", "rates": {}}`,
+		`{"date": "2026-10-16", "rates": {"USD": 1.08}}`,
+		`{"base": "EUR", "date": "16/10/2026", "rates": {"USD": 1.08}}`,
+	} {
+		if _, err := ParseRates([]byte(data)); err == nil {
+			t.Errorf("ParseRates(%s) succeeded", data)
+		}
+	}
+}
+
+func TestParseLocale(t *testing.T) {
+	tests := []struct {
+		name string
+		want Locale
+	}{
+		{"de_CH.UTF-8", Locale{Language: "de", Region: "CH"}},
+		{"en-CA", Locale{Language: "en", Region: "CA"}},
+		{"fr_FR@euro", Locale{Language: "fr", Region: "FR"}},
+		{"sv", Locale{Language: "sv"}},
+		{"C", Locale{Language: "en"}},
+		{"POSIX", Locale{Language: "en"}},
+		{"", Locale{Language: "en"}},
+	}
+	for _, tt := range tests {
+		if got := ParseLocale(tt.name); got != tt.want {
+			t.Errorf("ParseLocale(%q) = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormatIn(t *testing.T) {
+	tests := []struct {
+		m      types.Money
+		locale string
+		want   string
+	}{
+		{types.Money{Amount: 123450, Currency: "CAD"}, "en_US", "CA$1,234.50"},
+		{types.Money{Amount: 123450, Currency: "CAD"}, "en_CA", "$1,234.50"},
+		{types.Money{Amount: 123450, Currency: "USD"}, "en_CA", "US$1,234.50"},
+		{types.Money{Amount: 123450, Currency: "USD"}, "en_US", "$1,234.50"},
+		{types.Money{Amount: 123450, Currency: "EUR"}, "de_DE", "1.234,50\u00a0€"},
+		{types.Money{Amount: 123450, Currency: "EUR"}, "fr_FR", "1\u202f234,50\u00a0€"},
+		{types.Money{Amount: 123450, Currency: "EUR"}, "nl_NL", "€\u00a01.234,50"},
+		{types.Money{Amount: 123450, Currency: "CHF"}, "de_CH", "CHF\u00a01’234.50"},
+		{types.Money{Amount: 123450, Currency: "SEK"}, "sv_SE", "1\u00a0234,50\u00a0SEK"},
+		{types.Money{Amount: 1235, Currency: "JPY"}, "ja_JP", "¥1,235"},
+		{types.Money{Amount: 1234567, Currency: "KWD"}, "en_US", "KWD\u00a01,234.567"},
+		{types.Money{Amount: -1250, Currency: "GBP"}, "en_GB", "-£12.50"},
+		{types.Money{Amount: -1250, Currency: "EUR"}, "de_DE", "-12,50\u00a0€"},
+		{types.Money{Amount: 5, Currency: "USD"}, "C", "$0.05"},
+	}
+	// separators are non-breaking, so an amount never wraps
+	for _, tt := range tests {
+		if got := FormatIn(tt.m, ParseLocale(tt.locale)); got != tt.want {
+			t.Errorf("FormatIn(%v, %s) = %q, want %q", tt.m, tt.locale, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package money
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/justinm35/flyctl/types"
+)
+
+// Rates is a table of exchange rates against one base currency, as
+// published on a date.
+type Rates struct {
+	Base  string
+	Date  time.Time
+	rates map[string]*big.Rat // units of each currency per unit of Base
+}
+
+// NewRates builds a rate table from decimal strings, e.g. {"USD": "1.0842"}
+// against base "EUR".
+func NewRates(base string, date time.Time, rates map[string]string) (Rates, error) {
+	base = strings.ToUpper(base)
+	r := Rates{Base: base, Date: date, rates: map[string]*big.Rat{base: big.NewRat(1, 1)}}
+	for currency, value := range rates {
+		rate, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+		if !ok || rate.Sign() <= 0 {
+			return Rates{}, fmt.Errorf("rate for %s: invalid rate %q", currency, value)
+		}
+		r.rates[strings.ToUpper(currency)] = rate
+	}
+	return r, nil
+}
+
+// ParseRates reads rates in the JSON shape ECB-based services such as
+// frankfurter.app return:
+//
+//	{"base": "EUR", "date": "2026-10-16", "rates": {"USD": 1.0842, "CAD": 1.4711}}
+func ParseRates(data []byte) (Rates, error) {
+	var doc struct {
+		Base  string                 `json:"base"`
+		Date  string                 `json:"date"`
+		Rates map[string]json.Number `json:"rates"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return Rates{}, fmt.Errorf("decode rates: %w", err)
+	}
+	if doc.Base == "" || len(doc.Rates) == 0 {
+		return Rates{}, fmt.Errorf("decode rates: no base or rates")
+	}
+	date, err := time.Parse(time.DateOnly, doc.Date)
+	if err != nil {
+		return Rates{}, fmt.Errorf("decode rates: date: %w", err)
+	}
+	rates := make(map[string]string, len(doc.Rates))
+	for currency, n := range doc.Rates {
+		rates[currency] = n.String()
+	}
+	return NewRates(doc.Base, date, rates)
+}
+
+// Rate is how many units of to one unit of from buys.
+func (r Rates) Rate(from, to string) (*big.Rat, error) {
+	fromRate, ok := r.rates[strings.ToUpper(from)]
+	if !ok {
+		return nil, fmt.Errorf("no %s rate in the %s table of %s", from, r.Base, r.Date.Format(time.DateOnly))
+	}
+	toRate, ok := r.rates[strings.ToUpper(to)]
+	if !ok {
+		return nil, fmt.Errorf("no %s rate in the %s table of %s", to, r.Base, r.Date.Format(time.DateOnly))
+	}
+	return new(big.Rat).Quo(toRate, fromRate), nil
+}
+
+// Convert expresses m in currency to, rounded half away from zero to the
+// minor unit of to. It also returns the rate used.
+func (r Rates) Convert(m types.Money, to string) (types.Money, *big.Rat, error) {
+	to = strings.ToUpper(to)
+	rate, err := r.Rate(m.Currency, to)
+	if err != nil {
+		return types.Money{}, nil, err
+	}
+
+	// amount / 10^from * rate * 10^to
+	v := new(big.Rat).SetInt64(m.Amount)
+	v.Mul(v, rate)
+	v.Mul(v, new(big.Rat).SetFrac(big.NewInt(pow10(MinorUnits(to))), big.NewInt(pow10(MinorUnits(m.Currency)))))
+
+	amount, err := roundHalfAway(v)
+	if err != nil {
+		return types.Money{}, nil, fmt.Errorf("convert %s to %s: %w", Decimal(m), to, err)
+	}
+	return types.Money{Amount: amount, Currency: to}, rate, nil
+}
+
+// FormatRate renders a rate with up to six decimals and no trailing zeros.
+func FormatRate(rate *big.Rat) string {
+	s := rate.FloatString(6)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+func roundHalfAway(v *big.Rat) (int64, error) {
+	num := new(big.Int).Abs(v.Num())
+	q, rem := new(big.Int).QuoRem(num, v.Denom(), new(big.Int))
+	if rem.Lsh(rem, 1).Cmp(v.Denom()) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if v.Sign() < 0 {
+		q.Neg(q)
+	}
+	if !q.IsInt64() {
+		return 0, ErrOverflow
+	}
+	return q.Int64(), nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Line offsets inside the pane content, below the top border.
+const (
+	resultsHeaderLine   = 1 // column titles, right under "[Results]"
+	resultsFirstRowLine = 3 // after the titles and their rule
+)
+
+// updateMouse routes mouse events by position: a click focuses the pane under
+// the pointer and the wheel scrolls it, whichever pane has focus.
+func updateMouse(m Model, msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	// the palette is drawn over the panes and takes only keys
+	if m.palette.open {
+		return m, nil
+	}
+	if m.showHelp {
+		if msg.Action == tea.MouseActionPress {
+			m.showHelp = false
+		}
+		return m, nil
+	}
+
+	if msg.Y < m.layout.tabs.y+m.layout.tabs.height {
+		if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+			if i, ok := m.tabAt(msg.X); ok {
+				return m, m.switchTab(i)
+			}
+		}
+		return m, nil
+	}
+
+	switch m.screen {
+	case screenCompare:
+		if tea.MouseEvent(msg).IsWheel() {
+			var cmd tea.Cmd
+			m.compare.viewport, cmd = m.compare.viewport.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+	case screenSetup:
+		return m, nil
+	case screenHistory:
+		switch msg.Button {
+		case tea.MouseButtonWheelUp:
+			m.history.list.CursorUp()
+		case tea.MouseButtonWheelDown:
+			m.history.list.CursorDown()
+		}
+		return m, nil
+	}
+
+	target, ok := m.paneAt(msg.X, msg.Y)
+	if !ok {
+		return m, nil
+	}
+	r := m.layout.pane(target)
+	x, y := msg.X-r.x-1, msg.Y-r.y-1 // relative to the pane content
+
+	if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+		if allScreens[m.focusedPane] != target {
+			m.focusedPane = int(target)
+			m.applyLayout()
+		}
+	}
+
+	switch target {
+	case screenSearch:
+		if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft && m.screenSearch.mode == searchForm {
+			if i, ok := searchFormLayout(m).fieldAt(x, y); ok {
+				m.screenSearch.setFocus(i)
+			}
+		}
+		return m, nil
+	case screenResults:
+		return mouseResults(m, msg, x, y)
+	case screenFlightDetails:
+		if tea.MouseEvent(msg).IsWheel() {
+			var cmd tea.Cmd
+			m.screenFlightDetails.viewport, cmd = m.screenFlightDetails.viewport.Update(msg)
+			return m, cmd
+		}
+	}
+	return m, nil
+}
+
+func mouseResults(m Model, msg tea.MouseMsg, x, y int) (tea.Model, tea.Cmd) {
+	before := m.screenResults.cursor
+
+	switch {
+	case msg.Action != tea.MouseActionPress:
+		return m, nil
+	case msg.Button == tea.MouseButtonWheelUp:
+		m.screenResults.setCursor(m.screenResults.cursor - 1)
+	case msg.Button == tea.MouseButtonWheelDown:
+		m.screenResults.setCursor(m.screenResults.cursor + 1)
+	case msg.Button == tea.MouseButtonLeft && y == resultsHeaderLine:
+		if col, ok := m.screenResults.columnAt(x); ok {
+			m.screenResults.sortBy(col)
+		}
+	case msg.Button == tea.MouseButtonLeft && y >= resultsFirstRowLine:
+		if row, ok := m.screenResults.rowAtLine(y - resultsFirstRowLine); ok {
+			m.screenResults.setCursor(row)
+			return m, getFlightDetailsCmd(m)
+		}
+	}
+
+	if m.screenResults.cursor != before {
+		return m, getFlightDetailsCmd(m)
+	}
+	return m, nil
+}
+
+func (m Model) paneAt(x, y int) (screen, bool) {
+	for _, s := range allScreens {
+		if m.layout.pane(s).contains(x, y) {
+			return s, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/justinm35/flyctl/styles"
+	"github.com/sahilm/fuzzy"
+)
+
+const (
+	paletteWidth  = 60
+	paletteHeight = 12
+)
+
+// paletteAction is one entry in the command palette.
+type paletteAction struct {
+	title    string
+	shortcut string
+	run      func(m Model) (Model, tea.Cmd)
+}
+
+func (a paletteAction) label() string       { return a.title }
+func (a paletteAction) hint() string        { return a.shortcut }
+func (a paletteAction) FilterValue() string { return a.title }
+
+// PaletteState is the ctrl+p command palette: a fuzzy filter over every
+// action the app can run.
+type PaletteState struct {
+	open    bool
+	input   textinput.Model
+	list    list.Model
+	actions []paletteAction
+}
+
+func newPaletteState() PaletteState {
+	ti := textinput.New()
+	ti.Placeholder = "Type a command..."
+	ti.Prompt = "> "
+	ti.Width = paletteWidth - 4
+
+	l := list.New(nil, lineDelegate{}, paletteWidth, paletteHeight)
+	l.SetShowTitle(false)
+	l.SetShowStatusBar(false)
+	l.SetShowHelp(false)
+	l.SetFilteringEnabled(false)
+	l.DisableQuitKeybindings()
+
+	return PaletteState{input: ti, list: l}
+}
+
+// paletteActions builds the action list. It is rebuilt every time the palette
+// opens, so entries that depend on state (themes, providers) stay current.
+func paletteActions(m Model) []paletteAction {
+	actions := []paletteAction{
+		{title: "New search", run: func(m Model) (Model, tea.Cmd) {
+			m.screenSearch.reset()
+			m.focusPane(screenSearch)
+			return m, nil
+		}},
+		{title: "Swap origin and destination", run: func(m Model) (Model, tea.Cmd) {
+			m.screenSearch.swapRoute()
+			return m, nil
+		}},
+		{title: "Sort by price", run: func(m Model) (Model, tea.Cmd) {
+			m.screenResults.sortBy(colPrice)
+			return m, nil
+		}},
+		{title: "Sort by estimated total cost", run: func(m Model) (Model, tea.Cmd) {
+			m.screenResults.sortBy(colEstimatedTotal)
+			return m, nil
+		}},
+		{title: "Sort by emissions", run: func(m Model) (Model, tea.Cmd) {
+			m.screenResults.sortBy(colEmissions)
+			return m, nil
+		}},
+		{title: "Sort by departure time", run: func(m Model) (Model, tea.Cmd) {
+			m.screenResults.sortBy(colDeparture)
+			return m, nil
+		}},
+		{title: "Sort by duration", run: func(m Model) (Model, tea.Cmd) {
+			m.screenResults.sortBy(colDuration)
+			return m, nil
+		}},
+		{title: "Toggle filter: nonstop only", run: func(m Model) (Model, tea.Cmd) {
+			m.screenResults.toggleDirectOnly()
+			return m, getFlightDetailsCmd(m)
+		}},
+		{title: "Toggle filter: typical CO2 or less", run: func(m Model) (Model, tea.Cmd) {
+			m.screenResults.toggleLowCO2Only()
+			return m, getFlightDetailsCmd(m)
+		}},
+		{title: "Star selected offer", shortcut: m.keys.Results.Star.Help().Key, run: func(m Model) (Model, tea.Cmd) {
+			return markRowAsStarredCmd(m)
+		}},
+		{title: "Mark offer for comparison", shortcut: m.keys.Results.Mark.Help().Key, run: func(m Model) (Model, tea.Cmd) {
+			if err := m.screenResults.toggleCompared(); err != nil {
+				return m, m.setStatus(err.Error())
+			}
+			return m, nil
+		}},
+		{title: "Compare marked offers", shortcut: m.keys.Results.Compare.Help().Key, run: func(m Model) (Model, tea.Cmd) {
+			return m, m.openCompare()
+		}},
+		{title: "Export results to CSV", run: func(m Model) (Model, tea.Cmd) {
+			path, err := exportResultsCSV(m.screenResults.offers, m.screenResults.starred)
+			if err != nil {
+				return m, m.setStatus(fmt.Sprintf("export failed: %s", err))
+			}
+			return m, m.setStatus("exported results to " + path)
+		}},
+		{title: "New tab", shortcut: m.keys.Global.NewTab.Help().Key, run: func(m Model) (Model, tea.Cmd) {
+			return m, m.newTab()
+		}},
+		{title: "Close tab", shortcut: m.keys.Global.CloseTab.Help().Key, run: func(m Model) (Model, tea.Cmd) {
+			return m, m.closeTab()
+		}},
+		{title: "Next tab", shortcut: m.keys.Global.NextTab.Help().Key, run: func(m Model) (Model, tea.Cmd) {
+			return m, m.switchTab(m.activeTab + 1)
+		}},
+		{title: "Previous tab", shortcut: m.keys.Global.PrevTab.Help().Key, run: func(m Model) (Model, tea.Cmd) {
+			return m, m.switchTab(m.activeTab - 1)
+		}},
+		{title: "Search history", shortcut: m.keys.Global.History.Help().Key, run: func(m Model) (Model, tea.Cmd) {
+			return m, m.openHistory()
+		}},
+		{title: "Set up providers and defaults", run: func(m Model) (Model, tea.Cmd) {
+			return m, m.openSetup()
+		}},
+		{title: "Toggle log pane", shortcut: m.keys.Global.Logs.Help().Key, run: func(m Model) (Model, tea.Cmd) {
+			return m, m.toggleLogs()
+		}},
+		{title: "Maximize pane", shortcut: m.keys.Global.Maximize.Help().Key, run: func(m Model) (Model, tea.Cmd) {
+			m.maximized = !m.maximized
+			m.applyLayout()
+			return m, nil
+		}},
+		{title: "Show keybindings", shortcut: m.keys.Global.Help.Help().Key, run: func(m Model) (Model, tea.Cmd) {
+			m.showHelp = true
+			return m, nil
+		}},
+		{title: "Quit", shortcut: m.keys.Global.Quit.Help().Key, run: func(m Model) (Model, tea.Cmd) {
+			return m, tea.Quit
+		}},
+	}
+
+	actions = append(actions, paletteAction{
+		title:    "Save current search",
+		shortcut: m.keys.Search.Save.Help().Key,
+		run: func(m Model) (Model, tea.Cmd) {
+			m.focusPane(screenSearch)
+			return m, m.screenSearch.startNaming()
+		},
+	})
+	saved, err := loadSavedSearches()
+	if err != nil {
+		slog.Error("load saved searches", "err", err)
+	}
+	for _, saved := range saved {
+		actions = append(actions, paletteAction{
+			title: "Open saved search: " + saved.Name,
+			run: func(m Model) (Model, tea.Cmd) {
+				m.screenSearch.apply(saved.Query)
+				m.focusPane(screenSearch)
+				return m, m.startSearch()
+			},
+		})
+	}
+
+	for _, provider := range searchProviders {
+		actions = append(actions, paletteAction{
+			title: "Switch provider: " + provider,
+			run: func(m Model) (Model, tea.Cmd) {
+				m.screenSearch.provider = provider
+				return m, m.setStatus("provider set to " + provider)
+			},
+		})
+	}
+
+	for _, name := range profileNames(currentConfig()) {
+		actions = append(actions, paletteAction{
+			title: "Switch profile: " + name,
+			run: func(m Model) (Model, tea.Cmd) {
+				useProfile(name)
+				profile := activeProfile()
+				m.screenSearch.provider = profile.provider()
+				if m.screenSearch.inputs[0].Value() == "" {
+					m.screenSearch.inputs[0].SetValue(profile.Home)
+				}
+				return m, m.setStatus("profile set to " + name)
+			},
+		})
+	}
+
+	for _, name := range themeNames() {
+		actions = append(actions, paletteAction{
+			title: "Change theme: " + name,
+			run: func(m Model) (Model, tea.Cmd) {
+				theme, err := resolveTheme(name)
+				if err != nil {
+					return m, m.setStatus(err.Error())
+				}
+				styles.SetActive(theme)
+				m.applyTheme()
+				return m, nil
+			},
+		})
+	}
+
+	return actions
+}
+
+func (p *PaletteState) show(m Model) tea.Cmd {
+	p.open = true
+	p.actions = paletteActions(m)
+	p.input.Reset()
+	p.filter()
+	return p.input.Focus()
+}
+
+func (p *PaletteState) hide() {
+	p.open = false
+	p.input.Blur()
+}
+
+// filter ranks the actions against the current query.
+func (p *PaletteState) filter() {
+	query := strings.TrimSpace(p.input.Value())
+	var items []list.Item
+	if query == "" {
+		for _, a := range p.actions {
+			items = append(items, a)
+		}
+	} else {
+		for _, match := range fuzzy.FindFrom(query, paletteSource(p.actions)) {
+			items = append(items, p.actions[match.Index])
+		}
+	}
+	p.list.SetItems(items)
+	p.list.Select(0)
+}
+
+type paletteSource []paletteAction
+
+func (s paletteSource) String(i int) string { return s[i].title }
+func (s paletteSource) Len() int            { return len(s) }
+
+func updatePalette(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case msg.Type == tea.KeyEsc, key.Matches(msg, m.keys.Global.Palette):
+		m.palette.hide()
+		return m, nil
+	case msg.Type == tea.KeyEnter:
+		action, ok := m.palette.list.SelectedItem().(paletteAction)
+		m.palette.hide()
+		if !ok {
+			return m, nil
+		}
+		return action.run(m)
+	case msg.Type == tea.KeyUp, msg.Type == tea.KeyDown:
+		var cmd tea.Cmd
+		m.palette.list, cmd = m.palette.list.Update(msg)
+		return m, cmd
+	}
+
+	var cmd tea.Cmd
+	before := m.palette.input.Value()
+	m.palette.input, cmd = m.palette.input.Update(msg)
+	if m.palette.input.Value() != before {
+		m.palette.filter()
+	}
+	return m, cmd
+}
+
+func (m Model) viewPalette() string {
+	theme := styles.Active()
+	body := lipgloss.JoinVertical(
+		lipgloss.Left,
+		theme.Title().Render("[Commands]"),
+		m.palette.input.View(),
+		"",
+		m.palette.list.View(),
+	)
+	box := lipgloss.NewStyle().
+		BorderStyle(lipgloss.RoundedBorder()).
+		BorderForeground(theme.Accent).
+		Padding(0, 1).
+		Width(paletteWidth).
+		Render(body)
+
+	return lipgloss.Place(m.width, m.layout.full.height, lipgloss.Center, lipgloss.Center, box)
+}
+
+// lineItem is a list entry that renders on a single line.
+type lineItem interface {
+	label() string
+	hint() string
+}
+
+// lineDelegate renders list entries on a single line with the hint
+// right-aligned, in the colors of the active theme.
+type lineDelegate struct{}
+
+func (d lineDelegate) Height() int                             { return 1 }
+func (d lineDelegate) Spacing() int                            { return 0 }
+func (d lineDelegate) Update(_ tea.Msg, _ *list.Model) tea.Cmd { return nil }
+
+func (d lineDelegate) Render(w io.Writer, l list.Model, index int, item list.Item) {
+	entry, ok := item.(lineItem)
+	if !ok {
+		return
+	}
+	theme := styles.Active()
+	hint := entry.hint()
+	labelWidth := max(l.Width()-2-lipgloss.Width(hint), 0)
+	label := lipgloss.NewStyle().Width(labelWidth).MaxWidth(labelWidth).Render(entry.label())
+	if index == l.Index() {
+		fmt.Fprint(w, theme.Selected().Render(" "+label+hint+" "))
+		return
+	}
+	fmt.Fprint(w, " "+label+lipgloss.NewStyle().Foreground(theme.Muted).Render(hint)+" ")
+}
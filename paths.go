@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const appName = "flyctl"
+
+// configDir holds config.yaml: $XDG_CONFIG_HOME/flyctl, or ~/.config/flyctl.
+func configDir() (string, error) {
+	return xdgDir("XDG_CONFIG_HOME", ".config")
+}
+
+// stateDir holds data flyctl keeps between runs, the database and the log:
+// $XDG_STATE_HOME/flyctl, or ~/.local/state/flyctl.
+func stateDir() (string, error) {
+	return xdgDir("XDG_STATE_HOME", ".local/state")
+}
+
+// cacheDir holds data that can be fetched again, like exchange rates:
+// $XDG_CACHE_HOME/flyctl, or ~/.cache/flyctl.
+func cacheDir() (string, error) {
+	return xdgDir("XDG_CACHE_HOME", ".cache")
+}
+
+// xdgDir resolves an XDG base directory for flyctl. Relative values are
+// ignored, as the spec requires.
+func xdgDir(env, fallback string) (string, error) {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return filepath.Join(dir, appName), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home dir: %w", err)
+	}
+	return filepath.Join(home, fallback, appName), nil
+}
+
+// expandHome replaces a leading "~/" in a path from the config with the home
+// directory.
+func expandHome(path string) (string, error) {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home dir: %w", err)
+	}
+	return filepath.Join(home, rest), nil
+}
+
+// moveIfMissing moves a file left at an old location by earlier versions,
+// unless something already exists at the new one.
+func moveIfMissing(from, to string) error {
+	if _, err := os.Stat(to); !errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if _, err := os.Stat(from); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0o700); err != nil {
+		return err
+	}
+	return os.Rename(from, to)
+}
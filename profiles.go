@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/justinm35/flyctl/types"
+	"github.com/spf13/viper"
+)
+
+// Profile is one traveler's defaults, from an entry under "profiles":
+//
+//	profile: alex # used when --profile is not given
+//	profiles:
+//	  alex:
+//	    home: YYZ
+//	    currency: CAD
+//	    passengers: {adults: 2, children: 1}
+//	    cabin: premium_economy
+//	    providers: [amadeus, rapidgoogleflights]
+//	    exclude_airlines: [F8, Swoop]
+//	    luggage: {carry_on: 1, checked: 1}
+//
+// Anything a profile leaves out falls back to the top-level setting.
+type Profile struct {
+	Name            string     `mapstructure:"-"`
+	Home            string     `mapstructure:"home"`
+	Currency        string     `mapstructure:"currency"`
+	Passengers      Passengers `mapstructure:"passengers"`
+	Cabin           string     `mapstructure:"cabin"`
+	Providers       []string   `mapstructure:"providers"`
+	ExcludeAirlines []string   `mapstructure:"exclude_airlines"`
+	Luggage         *Luggage   `mapstructure:"luggage"`
+}
+
+type Passengers struct {
+	Adults   int `mapstructure:"adults"`
+	Children int `mapstructure:"children"`
+	Infants  int `mapstructure:"infants"` // on an adult's lap
+}
+
+var (
+	cabins      = []string{"economy", "premium_economy", "business", "first"}
+	airportCode = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// resolveProfile merges the named profile over the top-level settings. An
+// empty name gives the top-level settings alone.
+func (c Config) resolveProfile(name string) Profile {
+	p, _ := c.profile(name)
+	if p.Currency == "" {
+		p.Currency = c.Currency
+	}
+	if p.Passengers == (Passengers{}) {
+		p.Passengers.Adults = c.Adults
+	}
+	if p.Cabin == "" {
+		p.Cabin = "economy"
+	}
+	if len(p.Providers) == 0 {
+		p.Providers = []string{c.Provider}
+	}
+	if p.Luggage == nil {
+		p.Luggage = &c.Luggage
+	}
+	return p
+}
+
+// profile looks up a profile by name. viper lowercases keys, so the name
+// matches in any case and the result is named in lower case: "Work" in the
+// config comes back as "work".
+func (c Config) profile(name string) (Profile, bool) {
+	for key, p := range c.Profiles {
+		if strings.EqualFold(key, name) {
+			p.Name = key
+			return p, true
+		}
+	}
+	return Profile{Name: name}, false
+}
+
+// provider is the profile's preferred search provider.
+func (p Profile) provider() string { return p.Providers[0] }
+
+// excludes reports whether an offer flies with an airline the profile
+// excludes, matched by name or by the IATA code its flight numbers start
+// with.
+func (p Profile) excludes(offer types.FlightOffer) bool {
+	for _, seg := range offer.Segments {
+		for _, airline := range p.ExcludeAirlines {
+			code := strings.ToUpper(airline)
+			if strings.EqualFold(seg.Carrier, airline) || len(code) == 2 && strings.HasPrefix(strings.ToUpper(seg.FlightNo), code) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// filterOffers drops offers on excluded airlines.
+func (p Profile) filterOffers(offers []types.FlightOffer) []types.FlightOffer {
+	if len(p.ExcludeAirlines) == 0 {
+		return offers
+	}
+	return slices.DeleteFunc(offers, p.excludes)
+}
+
+// validateProfiles reports problems with every profile and with the
+// selected one, which has to exist.
+func (c Config) validateProfiles() []configProblem {
+	var problems []configProblem
+	if c.Profile != "" {
+		if _, ok := c.profile(c.Profile); !ok {
+			problems = append(problems, configProblem{"profile", fmt.Sprintf("no profile named %q; have %s", c.Profile, strings.Join(profileNames(c), ", "))})
+		}
+	}
+	for _, name := range profileNames(c) {
+		p := c.Profiles[name]
+		add := func(field, message string, args ...any) {
+			problems = append(problems, configProblem{"profiles." + name + "." + field, fmt.Sprintf(message, args...)})
+		}
+		if p.Home != "" && !airportCode.MatchString(p.Home) {
+			add("home", "must be a three-letter IATA airport code like YYZ, got %q", p.Home)
+		}
+		if p.Currency != "" && !currencyCode.MatchString(p.Currency) {
+			add("currency", "must be a three-letter ISO 4217 code like CAD, got %q", p.Currency)
+		}
+		if pax := p.Passengers; pax != (Passengers{}) {
+			switch {
+			case pax.Adults < 1:
+				add("passengers", "needs at least one adult")
+			case pax.Children < 0 || pax.Infants < 0:
+				add("passengers", "counts can't be negative")
+			case pax.Adults+pax.Children > 9:
+				add("passengers", "at most 9 seated travelers, got %d", pax.Adults+pax.Children)
+			case pax.Infants > pax.Adults:
+				add("passengers", "each infant needs an adult, got %s and %s", plural(pax.Infants, "infant"), plural(pax.Adults, "adult"))
+			}
+		}
+		if p.Cabin != "" && !slices.Contains(cabins, p.Cabin) {
+			add("cabin", "must be one of %s, got %q", strings.Join(cabins, ", "), p.Cabin)
+		}
+		if p.Luggage != nil {
+			problems = append(problems, p.Luggage.validate("profiles."+name+".luggage")...)
+		}
+		for _, provider := range p.Providers {
+			if !slices.Contains(searchProviders, provider) {
+				add("providers", "must be one of %s, got %q", strings.Join(searchProviders, ", "), provider)
+			}
+		}
+	}
+	return problems
+}
+
+func profileNames(c Config) []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// activeProfile is the profile picked with --profile, from the palette, or
+// with "profile" in the config.
+func activeProfile() Profile {
+	c := currentConfig()
+	return c.resolveProfile(c.Profile)
+}
+
+var (
+	configCacheMu sync.Mutex
+	configCache   *Config
+)
+
+// currentConfig is the running config, decoded once and kept until the
+// config changes. It was validated at startup and on every reload, so
+// errors are only logged.
+func currentConfig() Config {
+	configCacheMu.Lock()
+	defer configCacheMu.Unlock()
+	if configCache == nil {
+		configCache = new(Config)
+		if err := viper.Unmarshal(configCache); err != nil {
+			slog.Error("decode config", "err", err)
+		}
+	}
+	return *configCache
+}
+
+// forgetConfig drops the decoded config, so the next currentConfig decodes
+// the config viper has now.
+func forgetConfig() {
+	configCacheMu.Lock()
+	defer configCacheMu.Unlock()
+	configCache = nil
+}
+
+// useProfile switches the active profile for the rest of the session.
+func useProfile(name string) {
+	viper.Set("profile", name)
+	forgetConfig()
+}
@@ -5,23 +5,28 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
-	"math"
-	"math/big"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/justinm35/flyctl/money"
+	"github.com/justinm35/flyctl/providers/transport"
 	"github.com/justinm35/flyctl/types"
-	"github.com/spf13/viper"
 )
 
-const providerName = "amadeus"
+const ProviderName = "amadeus"
 
-func SearchFlights(ctx context.Context, searchQuery types.SearchRequest) ([]types.FlightOffer, error) {
-	client := &http.Client{}
+// Credentials are the API key and secret of an Amadeus for Developers app.
+type Credentials struct {
+	APIKey    string
+	APISecret string
+}
+
+func SearchFlights(ctx context.Context, creds Credentials, searchQuery types.SearchRequest) ([]types.FlightOffer, error) {
+	client := transport.NewClient(ProviderName)
 
 	u, _ := url.Parse("https://test.api.amadeus.com/v2/shopping/flight-offers")
 
@@ -31,28 +36,52 @@ func SearchFlights(ctx context.Context, searchQuery types.SearchRequest) ([]type
 	q.Set("departureDate", searchQuery.DepartDate.Format("2006-01-02"))
 	q.Set("adults", strconv.Itoa(searchQuery.Adults))
 	q.Set("max", strconv.Itoa(searchQuery.MaxResults))
+	if searchQuery.Children > 0 {
+		q.Set("children", strconv.Itoa(searchQuery.Children))
+	}
+	if searchQuery.Infants > 0 {
+		q.Set("infants", strconv.Itoa(searchQuery.Infants))
+	}
+	if searchQuery.Cabin != "" {
+		q.Set("travelClass", strings.ToUpper(searchQuery.Cabin))
+	}
+	if searchQuery.Currency != "" {
+		q.Set("currencyCode", searchQuery.Currency)
+	}
 
 	u.RawQuery = q.Encode()
 
-	req, err := http.NewRequest("GET", u.String(), nil)
+	bearer, err := getAmadeusBearer(ctx, client, creds)
+	if err != nil {
+		return nil, fmt.Errorf("amadeus auth: %w", err)
+	}
 
-	req.Header.Add("Authorization", getAmadeusBearer())
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Authorization", bearer)
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("amadeus search: %s", resp.Status)
 	}
 
 	var result SearchFlightResp
-	if err != nil {
-		log.Fatal(err)
-	} else {
-		dec := json.NewDecoder(resp.Body)
-		dec.Decode(&result)
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode amadeus response: %w", err)
 	}
 
-	adaptedRespone, _ := adaptSearchFlightResponse(result)
+	adaptedRespone, err := adaptSearchFlightResponse(result)
+	if err != nil {
+		slog.Warn("adapt response", "provider", ProviderName, "err", err)
+		return nil, err
+	}
 	return adaptedRespone, nil
 }
 
@@ -60,14 +89,29 @@ func adaptSearchFlightResponse(data SearchFlightResp) ([]types.FlightOffer, erro
 	offers := make([]types.FlightOffer, 0, len(data.Data))
 
 	for _, d := range data.Data {
-		money, err := parseMoneyMinorUnits(d.Price.Total, d.Price.Currency, 2) // assumes 2dp
+		price, err := money.Parse(d.Price.Total, d.Price.Currency)
 		if err != nil {
 			return nil, fmt.Errorf("parse price for offer %s: %w", d.ID, err)
 		}
+		fare, err := adaptFare(d.Price, d.TravelerPricings)
+		if err != nil {
+			return nil, fmt.Errorf("parse fare for offer %s: %w", d.ID, err)
+		}
 
+		cabins := segmentCabins(d.TravelerPricings)
 		var segs []types.Segment
+		// emissions are only known when every segment reports them
+		co2Grams, co2Known := 0, true
 		for _, itin := range d.Itineraries {
 			for _, s := range itin.Segments {
+				if len(s.Co2Emissions) == 0 {
+					co2Known = false
+				} else if e := s.Co2Emissions[0]; strings.EqualFold(e.WeightUnit, "KG") {
+					co2Grams += e.Weight * 1000
+				} else {
+					co2Known = false
+				}
+
 				departAt, err := parseTimeFlexible(s.Departure.At)
 				if err != nil {
 					return nil, fmt.Errorf("parse departure time for offer %s (%s->%s): %w",
@@ -97,65 +141,179 @@ func adaptSearchFlightResponse(data SearchFlightResp) ([]types.FlightOffer, erro
 					ArriveAt: arriveAt,
 					Carrier:  carrier,
 					FlightNo: flightNo,
-					Cabin:    "",
+					Cabin:    cabins[s.ID],
+					Aircraft: aircraftName(data, s.Aircraft.Code),
+
+					FromTerminal: s.Departure.Terminal,
+					ToTerminal:   s.Arrival.Terminal,
 				})
 			}
 		}
 
+		var emissions *types.Emissions
+		if co2Known && co2Grams > 0 {
+			emissions = &types.Emissions{Grams: co2Grams}
+		}
+
 		offers = append(offers, types.FlightOffer{
-			Provider: providerName,
-			OfferID:  d.ID,
-			TotalPrice: types.Money{
-				Amount:   money.Amount,
-				Currency: money.Currency,
-			},
-			Segments: segs,
+			Provider:   ProviderName,
+			OfferID:    d.ID,
+			TotalPrice: price,
+			Fare:       fare,
+			Baggage:    adaptBaggage(d.TravelerPricings),
+			Emissions:  emissions,
+			Segments:   segs,
 		})
 	}
 
 	return offers, nil
 }
 
-func getAmadeusBearer() string {
-	baseURL := "https://test.api.amadeus.com/v1/security/oauth2/token"
-	apiKey := viper.GetString("amadeus_api_key")
-	apiSecret := viper.GetString("amadeus_api_secret")
+// adaptFare splits the price into base fare, fees and taxes. Amadeus lists
+// fees but not the offer's taxes, which are whatever the total doesn't
+// account for.
+func adaptFare(p OfferPrice, travelers []TravelerPricing) (*types.Fare, error) {
+	if p.Base == "" {
+		return nil, nil
+	}
+	total, err := money.Parse(p.Total, p.Currency)
+	if err != nil {
+		return nil, err
+	}
+	base, err := money.Parse(p.Base, p.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("base: %w", err)
+	}
+	fare := &types.Fare{Base: base, Fees: types.Money{Currency: total.Currency}, GrandTotal: total}
+	for _, f := range p.Fees {
+		fee, err := money.Parse(f.Amount, p.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("%s fee: %w", f.Type, err)
+		}
+		fare.Fees.Amount += fee.Amount
+	}
+	fare.Taxes = types.Money{Amount: total.Amount - base.Amount - fare.Fees.Amount, Currency: total.Currency}
+	if p.GrandTotal != "" {
+		if fare.GrandTotal, err = money.Parse(p.GrandTotal, p.Currency); err != nil {
+			return nil, fmt.Errorf("grand total: %w", err)
+		}
+	}
+
+	for _, t := range travelers {
+		currency := t.Price.Currency
+		if currency == "" {
+			currency = p.Currency
+		}
+		tTotal, err := money.Parse(t.Price.Total, currency)
+		if err != nil {
+			return nil, fmt.Errorf("traveler %s: %w", t.TravelerID, err)
+		}
+		tBase, err := money.Parse(t.Price.Base, currency)
+		if err != nil {
+			return nil, fmt.Errorf("traveler %s base: %w", t.TravelerID, err)
+		}
+		fare.Travelers = append(fare.Travelers, types.TravelerFare{
+			ID:    t.TravelerID,
+			Type:  strings.ToLower(t.TravelerType),
+			Base:  tBase,
+			Taxes: types.Money{Amount: tTotal.Amount - tBase.Amount, Currency: tTotal.Currency},
+			Total: tTotal,
+		})
+	}
+	return fare, nil
+}
+
+// segmentCabins maps segment IDs to the first traveler's cabin on them,
+// e.g. "premium_economy".
+func segmentCabins(travelers []TravelerPricing) map[string]string {
+	cabins := map[string]string{}
+	if len(travelers) == 0 {
+		return cabins
+	}
+	for _, seg := range travelers[0].FareDetailsBySegment {
+		cabins[seg.SegmentID] = strings.ToLower(seg.Cabin)
+	}
+	return cabins
+}
 
-	client := &http.Client{}
+// aircraftName is the model name for a type code, e.g. "AIRBUS A320NEO",
+// or the code when the response doesn't name it.
+func aircraftName(data SearchFlightResp, code string) string {
+	if name := data.Dictionaries.Aircraft[code]; name != "" {
+		return name
+	}
+	return code
+}
+
+// adaptBaggage is the first traveler's allowance on the segment that allows
+// the least. Amadeus doesn't always list cabin bags, and one is then assumed.
+func adaptBaggage(travelers []TravelerPricing) *types.Baggage {
+	if len(travelers) == 0 {
+		return nil
+	}
+	var baggage *types.Baggage
+	for _, seg := range travelers[0].FareDetailsBySegment {
+		if seg.IncludedCheckedBags == nil {
+			return nil
+		}
+		b := types.Baggage{CarryOn: 1, Checked: seg.IncludedCheckedBags.bags()}
+		if seg.IncludedCabinBags != nil {
+			b.CarryOn = seg.IncludedCabinBags.bags()
+		}
+		if baggage == nil {
+			baggage = &b
+			continue
+		}
+		baggage.CarryOn = min(baggage.CarryOn, b.CarryOn)
+		baggage.Checked = min(baggage.Checked, b.Checked)
+	}
+	return baggage
+}
+
+func getAmadeusBearer(ctx context.Context, client *http.Client, creds Credentials) (string, error) {
+	baseURL := "https://test.api.amadeus.com/v1/security/oauth2/token"
 
-	reqBody := fmt.Sprintf("grant_type=client_credentials&client_id=%s&client_secret=%s", apiKey, apiSecret)
-	reqBodyReader := strings.NewReader(reqBody)
-	req, err := http.NewRequest("POST", baseURL, reqBodyReader)
+	reqBody := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {creds.APIKey},
+		"client_secret": {creds.APISecret},
+	}.Encode()
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL, strings.NewReader(reqBody))
 	if err != nil {
-		log.Fatal(err)
+		return "", err
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Fatal(err)
+		return "", fmt.Errorf("request failed: %w", err)
 	}
+	defer resp.Body.Close()
 
-	respBodyMap := responseToMap(resp.Body)
+	respBodyMap, err := responseToMap(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	accessToken, _ := respBodyMap["access_token"].(string)
+	if resp.StatusCode != http.StatusOK || accessToken == "" {
+		return "", fmt.Errorf("no access token: %s", resp.Status)
+	}
 
-	accessToken := respBodyMap["access_token"]
 	bearerPrefix := "Bearer"
-	fullBearerToken := fmt.Sprintf("%s %s", bearerPrefix, accessToken)
-	fmt.Println("FULL  BEARER", fullBearerToken)
-
-	return fullBearerToken
+	return fmt.Sprintf("%s %s", bearerPrefix, accessToken), nil
 }
 
-func responseToMap(responseBody io.ReadCloser) map[string]any {
+func responseToMap(responseBody io.Reader) (map[string]any, error) {
 	bodyBytes, err := io.ReadAll(responseBody)
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("read response: %w", err)
 	}
 
 	var jsonMap map[string]any
-	json.Unmarshal([]byte(bodyBytes), &jsonMap)
-
-	return jsonMap
+	if err := json.Unmarshal(bodyBytes, &jsonMap); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return jsonMap, nil
 }
 
 func parseTimeFlexible(s string) (time.Time, error) {
@@ -171,40 +329,3 @@ func parseTimeFlexible(s string) (time.Time, error) {
 	}
 	return time.Time{}, fmt.Errorf("unsupported time format: %q", s)
 }
-
-// parseMoneyMinorUnits converts a decimal string (e.g. "123.45") into minor units (e.g. 12345).
-// decimals=2 is typical, but some currencies differ (JPY=0, etc.).
-func parseMoneyMinorUnits(amountStr, currency string, decimals int) (types.Money, error) {
-	amountStr = strings.TrimSpace(amountStr)
-	if amountStr == "" {
-		return types.Money{}, fmt.Errorf("empty amount")
-	}
-
-	r := new(big.Rat)
-	if _, ok := r.SetString(amountStr); !ok {
-		return types.Money{}, fmt.Errorf("invalid decimal %q", amountStr)
-	}
-
-	scale := new(big.Rat).SetInt(big.NewInt(int64Pow10(decimals)))
-	r.Mul(r, scale) // amount * 10^decimals
-
-	// Round half away from zero to nearest int64
-	f, _ := r.Float64()
-	rounded := int64(math.Round(f))
-
-	return types.Money{
-		Amount:   rounded,
-		Currency: strings.TrimSpace(currency),
-	}, nil
-}
-
-func int64Pow10(n int) int64 {
-	if n <= 0 {
-		return 1
-	}
-	x := int64(1)
-	for i := 0; i < n; i++ {
-		x *= 10
-	}
-	return x
-}
@@ -13,6 +13,7 @@ type SearchFlightResp struct {
 		Itineraries           []struct {
 			Duration string `json:"duration"`
 			Segments []struct {
+				ID        string `json:"id"`
 				Departure struct {
 					IataCode string `json:"iataCode"`
 					Terminal string `json:"terminal"`
@@ -28,15 +29,64 @@ type SearchFlightResp struct {
 				Operating   struct {
 					CarrierCode string `json:"carrierCode"`
 				} `json:"operating"`
+				Aircraft struct {
+					Code string `json:"code"`
+				} `json:"aircraft"`
 				Duration      string `json:"duration"`
 				NumberOfStops int    `json:"numberOfStops"`
+				Co2Emissions  []struct {
+					Weight     int    `json:"weight"`
+					WeightUnit string `json:"weightUnit"`
+					Cabin      string `json:"cabin"`
+				} `json:"co2Emissions"`
 			} `json:"segments"`
 		} `json:"itineraries"`
-		Price struct {
-			Currency   string `json:"currency"`
-			Total      string `json:"total"`
-			Base       string `json:"base"`
-			GrandTotal string `json:"grandTotal"`
-		} `json:"price"`
+		Price            OfferPrice        `json:"price"`
+		TravelerPricings []TravelerPricing `json:"travelerPricings"`
 	} `json:"data"`
+	Dictionaries struct {
+		Aircraft map[string]string `json:"aircraft"` // type code to model name
+	} `json:"dictionaries"`
+}
+
+type OfferPrice struct {
+	Currency string `json:"currency"`
+	Total    string `json:"total"`
+	Base     string `json:"base"`
+	Fees     []struct {
+		Amount string `json:"amount"`
+		Type   string `json:"type"`
+	} `json:"fees"`
+	GrandTotal string `json:"grandTotal"`
+}
+
+type TravelerPricing struct {
+	TravelerID   string `json:"travelerId"`
+	TravelerType string `json:"travelerType"`
+	Price        struct {
+		Currency string `json:"currency"`
+		Total    string `json:"total"`
+		Base     string `json:"base"`
+	} `json:"price"`
+	FareDetailsBySegment []struct {
+		SegmentID           string       `json:"segmentId"`
+		Cabin               string       `json:"cabin"`
+		IncludedCheckedBags *IncludedBag `json:"includedCheckedBags"`
+		IncludedCabinBags   *IncludedBag `json:"includedCabinBags"`
+	} `json:"fareDetailsBySegment"`
+}
+
+type IncludedBag struct {
+	Quantity   int    `json:"quantity"`
+	Weight     int    `json:"weight"`
+	WeightUnit string `json:"weightUnit"`
+}
+
+// bags is how many bags the allowance covers; an allowance given only by
+// weight is one bag.
+func (b *IncludedBag) bags() int {
+	if b.Quantity == 0 && b.Weight > 0 {
+		return 1
+	}
+	return b.Quantity
 }
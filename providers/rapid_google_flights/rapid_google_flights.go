@@ -3,29 +3,34 @@ package rapidgoogleflights
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/justinm35/flyctl/money"
+	"github.com/justinm35/flyctl/providers/transport"
 	"github.com/justinm35/flyctl/types"
-	"github.com/spf13/viper"
 )
 
-const providerName = "rapidgoogleflights"
+const ProviderName = "rapidgoogleflights"
 
 type GetSearchResultsInput struct {
+	APIKey          string
 	SourceIata      string
 	DestinationIata string
 	DepartureDate   string
 	Adults          int
+	Children        int
+	Infants         int    // on an adult's lap
+	Cabin           string // economy, premium_economy, business or first
 	Currency        string
 }
 
 func SearchFlights(input GetSearchResultsInput) ([]types.FlightOffer, error) {
-	client := &http.Client{}
+	client := transport.NewClient(ProviderName)
 
 	u, _ := url.Parse("https://google-flights2.p.rapidapi.com/api/v1/searchFlights")
 
@@ -36,8 +41,15 @@ func SearchFlights(input GetSearchResultsInput) ([]types.FlightOffer, error) {
 	q.Set("arrival_id", input.DestinationIata)
 	q.Set("outbound_date", input.DepartureDate)
 	q.Set("adults", strconv.Itoa(input.Adults))
+	if input.Children > 0 {
+		q.Set("children", strconv.Itoa(input.Children))
+	}
+	if input.Infants > 0 {
+		q.Set("infant_on_lap", strconv.Itoa(input.Infants))
+	}
 
-	log.Printf("SearchFlights query: \n sourceIata: %s \n arrivalIata: %s \n deparureDate: %s \n", input.SourceIata, input.DestinationIata, input.DepartureDate)
+	slog.Debug("search query", "provider", ProviderName,
+		"origin", input.SourceIata, "destination", input.DestinationIata, "departure_date", input.DepartureDate)
 
 	currency := input.Currency
 	if currency == "" {
@@ -45,8 +57,13 @@ func SearchFlights(input GetSearchResultsInput) ([]types.FlightOffer, error) {
 	}
 	q.Set("currency", currency)
 
+	travelClass := "ECONOMY"
+	if input.Cabin != "" {
+		travelClass = strings.ToUpper(input.Cabin)
+	}
+	q.Set("travel_class", travelClass)
+
 	// defaults
-	q.Set("travel_class", "ECONOMY")
 	q.Set("show_hidden", "1")
 	q.Set("language_code", "en-US")
 	q.Set("country_code", "CA")
@@ -54,33 +71,41 @@ func SearchFlights(input GetSearchResultsInput) ([]types.FlightOffer, error) {
 
 	u.RawQuery = q.Encode()
 	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
 
-	rapid_api_key := viper.GetString("rapid_google_api_key")
-	req.Header.Add("x-rapidapi-key", rapid_api_key)
+	req.Header.Add("x-rapidapi-key", input.APIKey)
 	req.Header.Set("x-rapidapi-host", "google-flights2.p.rapidapi.com")
 
 	resp, err := client.Do(req)
-
 	if err != nil {
-		log.Printf("SearchFlights Error: %s \n", string(err.Error()))
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
+	defer resp.Body.Close()
 
 	var result SearchFlightResp
 
 	dec := json.NewDecoder(resp.Body)
-	dec.Decode(&result)
+	if err := dec.Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode response (%s): %w", resp.Status, err)
+	}
 
 	if !result.Status {
 		return nil, fmt.Errorf("rapidapi google flights error: %s", flattenMessages(result.Message))
 	}
 
-	adaptedRespone, _ := adaptSearchFlightResponse(result)
+	// prices come back in the currency asked for, as whole units
+	adaptedRespone, err := adaptSearchFlightResponse(result, currency, strings.ToLower(travelClass))
+	if err != nil {
+		slog.Warn("adapt response", "provider", ProviderName, "err", err)
+		return nil, err
+	}
 
 	return adaptedRespone, nil
 }
 
-func adaptSearchFlightResponse(data SearchFlightResp) ([]types.FlightOffer, error) {
+func adaptSearchFlightResponse(data SearchFlightResp, currency, cabin string) ([]types.FlightOffer, error) {
 	all := make([]FlightOption, 0, len(data.Data.Itineraries.TopFlights)+len(data.Data.Itineraries.OtherFlights))
 	all = append(all, data.Data.Itineraries.TopFlights...)
 	all = append(all, data.Data.Itineraries.OtherFlights...)
@@ -90,7 +115,7 @@ func adaptSearchFlightResponse(data SearchFlightResp) ([]types.FlightOffer, erro
 	for i, opt := range all {
 		segs := make([]types.Segment, 0, len(opt.Flights))
 
-		for _, leg := range opt.Flights {
+		for j, leg := range opt.Flights {
 			departAt, err := time.Parse("2006-1-2 15:04", strings.TrimSpace(leg.DepartureAirport.Time))
 			if err != nil {
 				return nil, fmt.Errorf("parse departure time %q: %w", leg.DepartureAirport.Time, err)
@@ -108,7 +133,13 @@ func adaptSearchFlightResponse(data SearchFlightResp) ([]types.FlightOffer, erro
 				ArriveAt: arriveAt,
 				Carrier:  strings.TrimSpace(leg.Airline),
 				FlightNo: strings.TrimSpace(leg.FlightNumber),
-				Cabin:    "", // not provided in this payload
+				Cabin:    cabin, // the one searched; legs don't say
+				Aircraft: strings.TrimSpace(leg.Aircraft),
+				Legroom:  strings.TrimSpace(leg.Legroom),
+				Seat:     strings.TrimSpace(leg.Seat),
+
+				Amenities:    amenities(leg.Extensions),
+				SelfTransfer: j > 0 && selfTransfer(leg.Extensions),
 			})
 		}
 
@@ -117,20 +148,62 @@ func adaptSearchFlightResponse(data SearchFlightResp) ([]types.FlightOffer, erro
 			offerID = fmt.Sprintf("offer-%d-%d", data.Timestamp, i)
 		}
 
+		// sold-out and "price unavailable" options come without a price
+		price, err := money.Parse(opt.Price.String(), currency)
+		if err != nil {
+			slog.Warn("skip offer without a price", "provider", ProviderName, "offer", offerID, "err", err)
+			continue
+		}
+
+		var baggage *types.Baggage
+		if opt.Bags.Checked != nil {
+			baggage = &types.Baggage{CarryOn: opt.Bags.CarryOn, Checked: *opt.Bags.Checked}
+		}
+
+		var emissions *types.Emissions
+		if opt.Carbon.CO2e > 0 {
+			emissions = &types.Emissions{Grams: opt.Carbon.CO2e, TypicalGrams: opt.Carbon.TypicalForRoute}
+		}
+
 		offers = append(offers, types.FlightOffer{
-			Provider: providerName,
-			OfferID:  offerID,
-			TotalPrice: types.Money{
-				Amount:   int64(opt.Price) * 100, // simple: major -> minor
-				Currency: "USD",                  // payload doesn’t include currency; set default or plumb it in
-			},
-			Segments: segs,
+			Provider:   ProviderName,
+			OfferID:    offerID,
+			TotalPrice: price,
+			Baggage:    baggage,
+			Emissions:  emissions,
+			Segments:   segs,
 		})
 	}
 
 	return offers, nil
 }
 
+// amenities drops the extensions that repeat what the leg already says.
+func amenities(extensions []string) []string {
+	var out []string
+	for _, e := range extensions {
+		e = strings.TrimSpace(e)
+		lower := strings.ToLower(e)
+		if e == "" || strings.Contains(lower, "legroom") || strings.Contains(lower, "emissions estimate") || selfTransfer([]string{e}) {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// selfTransfer reports whether the extensions say a leg is ticketed
+// separately.
+func selfTransfer(extensions []string) bool {
+	for _, e := range extensions {
+		lower := strings.ToLower(e)
+		if strings.Contains(lower, "self transfer") || strings.Contains(lower, "self-transfer") || strings.Contains(lower, "separate ticket") {
+			return true
+		}
+	}
+	return false
+}
+
 func flattenMessages(msg []map[string]string) string {
 	if len(msg) == 0 {
 		return "unknown error"
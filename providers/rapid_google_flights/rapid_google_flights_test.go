@@ -0,0 +1,46 @@
+package rapidgoogleflights
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAdaptSkipsOffersWithoutPrice(t *testing.T) {
+	var resp SearchFlightResp
+	err := json.Unmarshal([]byte(`{"timestamp": 1, "data": {"itineraries": {
+		"topFlights": [
+			{"price": 612, "next_token": "priced", "flights": [{
+				"departure_airport": {"airport_code": "YYZ", "time": "2026-11-1 18:05"},
+				"arrival_airport": {"airport_code": "CPH", "time": "2026-11-2 8:15"}
+			}]},
+			{"next_token": "no price", "flights": []}
+		],
+		"otherFlights": [
+			{"price": null, "next_token": "null price", "flights": []},
+			{"price": 701.5, "next_token": "also priced", "flights": []}
+		]
+	}}}`), &resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offers, err := adaptSearchFlightResponse(resp, "CAD", "economy")
+	if err != nil {
+		t.Fatalf("adaptSearchFlightResponse: %v", err)
+	}
+	tests := []struct {
+		id     string
+		amount int64
+	}{
+		{"priced", 61200},
+		{"also priced", 70150},
+	}
+	if len(offers) != len(tests) {
+		t.Fatalf("%d offers, want %d", len(offers), len(tests))
+	}
+	for i, tt := range tests {
+		if offers[i].OfferID != tt.id || offers[i].TotalPrice.Amount != tt.amount {
+			t.Errorf("offer %d = %s at %d, want %s at %d", i, offers[i].OfferID, offers[i].TotalPrice.Amount, tt.id, tt.amount)
+		}
+	}
+}
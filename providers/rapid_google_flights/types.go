@@ -1,5 +1,7 @@
 package rapidgoogleflights
 
+import "encoding/json"
+
 type SearchFlightResp struct {
 	Status    bool                `json:"status"`
 	Message   []map[string]string `json:"message"`
@@ -19,7 +21,7 @@ type FlightOption struct {
 	Layovers      []Layover       `json:"layovers"`
 	Bags          BagsInfo        `json:"bags"`
 	Carbon        CarbonEmissions `json:"carbon_emissions"`
-	Price         int             `json:"price"`
+	Price         json.Number     `json:"price"`
 	Stops         int             `json:"stops"`
 	AirlineLogo   string          `json:"airline_logo"`
 	NextToken     string          `json:"next_token"`
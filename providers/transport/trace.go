@@ -0,0 +1,179 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tracer writes one JSON line per provider request when --trace-http is on.
+type tracer struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	bodies bool
+}
+
+var activeTracer *tracer
+
+// EnableTracing records every provider request and response to w as JSON
+// lines, with credentials redacted. Bodies are only recorded when
+// withBodies is set. Call it before any request is made.
+func EnableTracing(w io.Writer, withBodies bool) {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	activeTracer = &tracer{enc: enc, bodies: withBodies}
+}
+
+type traceRecord struct {
+	Time            time.Time   `json:"time"`
+	Provider        string      `json:"provider"`
+	RequestID       string      `json:"request_id"`
+	Method          string      `json:"method"`
+	URL             string      `json:"url"`
+	RequestHeaders  http.Header `json:"request_headers"`
+	RequestBody     string      `json:"request_body,omitempty"`
+	Status          int         `json:"status,omitempty"`
+	DurationMS      float64     `json:"duration_ms"`
+	ResponseSize    int         `json:"response_size"`
+	ResponseHeaders http.Header `json:"response_headers,omitempty"`
+	ResponseBody    string      `json:"response_body,omitempty"`
+	Error           string      `json:"error,omitempty"`
+}
+
+// begin records the request side. The request body is read and put back so
+// the request can still be sent.
+func (t *tracer) begin(provider, id string, req *http.Request) *traceRecord {
+	rec := &traceRecord{
+		Time:           time.Now(),
+		Provider:       provider,
+		RequestID:      id,
+		Method:         req.Method,
+		URL:            redactURL(req.URL),
+		RequestHeaders: redactHeaders(req.Header),
+	}
+	if t.bodies && req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		if err == nil {
+			rec.RequestBody = redactBody(req.Header.Get("Content-Type"), body)
+		}
+	}
+	return rec
+}
+
+// finish records the response side and writes the record. The response
+// body is read to measure it and replaced with an in-memory copy.
+func (t *tracer) finish(rec *traceRecord, resp *http.Response, err error) {
+	rec.DurationMS = float64(time.Since(rec.Time).Microseconds()) / 1000
+	if err != nil {
+		rec.Error = err.Error()
+	} else {
+		rec.Status = resp.StatusCode
+		rec.ResponseHeaders = redactHeaders(resp.Header)
+		body, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		rec.ResponseSize = len(body)
+		if readErr != nil {
+			rec.Error = readErr.Error()
+		}
+		if t.bodies {
+			rec.ResponseBody = redactBody(resp.Header.Get("Content-Type"), body)
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.enc.Encode(rec)
+}
+
+const redacted = "REDACTED"
+
+// sensitiveName matches header, query and form field names that carry
+// credentials: Authorization, x-rapidapi-key, client_secret, apikey...
+var sensitiveName = regexp.MustCompile(`(?i)^(.*authorization|.*cookie|.*api[-_]?key|.*secret|.*token|password|client_id)$`)
+
+// sensitiveField matches JSON fields that carry credentials. It is narrower
+// than sensitiveName because provider payloads use "token" for paging.
+var sensitiveField = regexp.MustCompile(`(?i)^(access_token|refresh_token|id_token|client_secret|api_?key|password)$`)
+
+// sensitivePair matches name=value pairs with a sensitiveName, for form
+// bodies too malformed to parse. The value runs to the next separator.
+var sensitivePair = regexp.MustCompile(`(?i)((?:^|[&;])(?:[^=&;]*(?:authorization|cookie|api[-_]?key|secret|token)|password|client_id)=)[^&;]*`)
+
+var bearerToken = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`)
+
+func redactHeaders(h http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for name, values := range h {
+		if sensitiveName.MatchString(name) {
+			out[name] = []string{redacted}
+			continue
+		}
+		out[name] = values
+	}
+	return out
+}
+
+func redactURL(u *url.URL) string {
+	c := *u
+	c.User = nil
+	q := c.Query()
+	for name := range q {
+		if sensitiveName.MatchString(name) {
+			q.Set(name, redacted)
+		}
+	}
+	c.RawQuery = q.Encode()
+	return c.String()
+}
+
+func redactBody(contentType string, body []byte) string {
+	switch {
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			for name := range form {
+				if sensitiveName.MatchString(name) {
+					form.Set(name, redacted)
+				}
+			}
+			return form.Encode()
+		}
+		body = sensitivePair.ReplaceAll(body, []byte("${1}"+redacted))
+	case json.Valid(body):
+		var v any
+		if err := json.Unmarshal(body, &v); err == nil {
+			if out, err := json.Marshal(redactJSON(v)); err == nil {
+				return string(out)
+			}
+		}
+	}
+	return bearerToken.ReplaceAllString(string(body), "${1}"+redacted)
+}
+
+func redactJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if _, isString := child.(string); isString && sensitiveField.MatchString(k) {
+				v[k] = redacted
+				continue
+			}
+			v[k] = redactJSON(child)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = redactJSON(child)
+		}
+	case string:
+		return bearerToken.ReplaceAllString(v, "${1}"+redacted)
+	}
+	return v
+}
@@ -0,0 +1,64 @@
+// Package transport is the HTTP plumbing shared by the providers: every
+// request gets an ID and is logged with its status and duration, and can be
+// traced to a JSONL file.
+package transport
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// NewClient returns an HTTP client for a provider.
+func NewClient(provider string) *http.Client {
+	return &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &loggingTransport{provider: provider, base: http.DefaultTransport},
+	}
+}
+
+type loggingTransport struct {
+	provider string
+	base     http.RoundTripper
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := newRequestID()
+	logger := slog.With("provider", t.provider, "request_id", id)
+	logger.DebugContext(req.Context(), "provider request", "method", req.Method, "host", req.URL.Host, "path", req.URL.Path)
+
+	var trace *traceRecord
+	if activeTracer != nil {
+		// RoundTrippers must not modify the caller's request
+		req = req.Clone(req.Context())
+		trace = activeTracer.begin(t.provider, id, req)
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	duration := time.Since(start)
+	if trace != nil {
+		activeTracer.finish(trace, resp, err)
+	}
+	if err != nil {
+		logger.ErrorContext(req.Context(), "provider request failed",
+			"method", req.Method, "path", req.URL.Path, "duration", duration, "err", err)
+		return nil, err
+	}
+
+	level := slog.LevelInfo
+	if resp.StatusCode >= 400 {
+		level = slog.LevelWarn
+	}
+	logger.Log(req.Context(), level, "provider response",
+		"method", req.Method, "path", req.URL.Path, "status", resp.StatusCode, "duration", duration)
+	return resp, nil
+}
+
+func newRequestID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
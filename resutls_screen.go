@@ -1,6 +1,14 @@
 package main
 
 import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -11,50 +19,71 @@ import (
 
 type ResultsState struct {
 	table         table.Model
-	offers        []types.FlightOffer
+	allOffers     []types.FlightOffer // everything the last search returned
+	offers        []types.FlightOffer // the offers shown, in table order
 	formattedRows []table.Row
+	cursor        int             // the selected row, an index into offers
+	top           int             // the row at the top of the table body
+	starred       map[string]bool // keyed by offer ID
+	compared      []string        // offer IDs marked for comparison, in slot order
+	directOnly    bool
+	lowCO2Only    bool
+	width         int
+	height        int
+	keys          resultsKeyMap
+	sortColumn    int
+	sortDesc      bool
 	err           string
 }
 
-func (resultsState *ResultsState) setTableWidth(width int) {
-	inner := width - 14
-	if inner < 40 {
-		inner = width
-	}
+const maxCompared = 4
 
-	starredW := int(0.01 * float64(inner))
-	routeW := int(0.18 * float64(inner))
-	departureW := int(0.14 * float64(inner))
-	arrivalW := int(0.14 * float64(inner))
-	durationW := int(0.20 * float64(inner))
-	priceW := int(0.10 * float64(inner))
-	carrierW := int(0.18 * float64(inner))
+// Results table columns, in display order.
+const (
+	colStarred = iota
+	colRoute
+	colDeparture
+	colArrival
+	colDuration
+	colPrice
+	colEstimatedTotal
+	colEmissions
+	colConnections
+	colCarrier
+)
 
-	columns := []table.Column{
-		{Title: "", Width: starredW},
-		{Title: "Route", Width: routeW},
-		{Title: "Departure Time", Width: departureW},
-		{Title: "Arrival Time", Width: arrivalW},
-		{Title: "Duration", Width: durationW},
-		{Title: "Price", Width: priceW},
-		{Title: "Carrier", Width: carrierW},
-	}
+// resize fits the table to the inner size of the results pane. One line is
+// reserved for the pane title.
+func (resultsState *ResultsState) resize(width, height int) {
+	resultsState.width = width
+	resultsState.height = height
+	resultsState.setTableWidth(width)
+	resultsState.table.SetHeight(max(height-1, 3))
+	resultsState.setCursor(resultsState.cursor)
+}
 
-	resultsState.table.SetColumns(columns)
+func (resultsState *ResultsState) setTableWidth(width int) {
+	resultsState.table.SetColumns(resultsState.tableColumns(width))
+	resultsState.table.SetWidth(width)
 }
-func (resultsState *ResultsState) buildTable(width int) {
-	inner := width - 14
+
+func (resultsState *ResultsState) tableColumns(width int) []table.Column {
+	// every cell has one column of padding on each side
+	inner := width - 20
 	if inner < 40 {
 		inner = width
 	}
 
-	starredW := int(0.01 * float64(inner))
-	routeW := int(0.18 * float64(inner))
-	departureW := int(0.14 * float64(inner))
-	arrivalW := int(0.14 * float64(inner))
-	durationW := int(0.20 * float64(inner))
-	priceW := int(0.10 * float64(inner))
-	carrierW := int(0.18 * float64(inner))
+	starredW := max(int(0.01*float64(inner)), 2)
+	routeW := int(0.13 * float64(inner))
+	departureW := int(0.11 * float64(inner))
+	arrivalW := int(0.11 * float64(inner))
+	durationW := int(0.14 * float64(inner))
+	priceW := int(0.09 * float64(inner))
+	estimatedTotalW := int(0.10 * float64(inner))
+	emissionsW := int(0.09 * float64(inner))
+	connectionsW := int(0.10 * float64(inner))
+	carrierW := int(0.12 * float64(inner))
 
 	columns := []table.Column{
 		{Title: "", Width: starredW},
@@ -63,101 +92,260 @@ func (resultsState *ResultsState) buildTable(width int) {
 		{Title: "Arrival Time", Width: arrivalW},
 		{Title: "Duration", Width: durationW},
 		{Title: "Price", Width: priceW},
+		{Title: "Est. total", Width: estimatedTotalW},
+		{Title: "CO2", Width: emissionsW},
+		{Title: "Connections", Width: connectionsW},
 		{Title: "Carrier", Width: carrierW},
 	}
+	if resultsState.sortColumn > colStarred && resultsState.sortColumn < len(columns) {
+		arrow := " ▲"
+		if resultsState.sortDesc {
+			arrow = " ▼"
+		}
+		columns[resultsState.sortColumn].Title += arrow
+	}
+	return columns
+}
+
+func (resultsState *ResultsState) buildTable() {
+	width := resultsState.width
+	if width == 0 {
+		width = 100
+	}
 	rows := utils.FormatResponseData(resultsState.offers)
+	for i, offer := range resultsState.offers {
+		rows[i][colStarred] = resultsState.marker(offer.OfferID)
+		rows[i][colConnections] = layoverRules.connectionSummary(offer)
+	}
 
 	resultsState.formattedRows = rows
+	resultsState.sortColumn = -1
+	resultsState.sortDesc = false
 
 	t := table.New(
-		table.WithColumns(columns),
-		table.WithRows(rows),
+		table.WithColumns(resultsState.tableColumns(width)),
 		table.WithFocused(true),
 	)
-
-	s := table.DefaultStyles()
-	s.Header = s.Header.
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color("240")).
-		BorderBottom(true).
-		Bold(true)
-	s.Selected = s.Selected.
-		Foreground(lipgloss.Color("229")).
-		Background(lipgloss.Color("57")).
-		Bold(true)
-	t.SetStyles(s)
+	t.SetStyles(tableStyles(styles.Active()))
 	resultsState.table = t
+	resultsState.cursor, resultsState.top = 0, 0
+	if resultsState.height > 0 {
+		resultsState.resize(resultsState.width, resultsState.height)
+	} else {
+		resultsState.setCursor(0)
+	}
 }
 
-func newResultsState() ResultsState {
-	storedFlightOffers := FetchStoredData[[]types.FlightOffer]("allOffers")
-	if storedFlightOffers != nil || len(storedFlightOffers) > 0 {
-		storedScreenResults := ResultsState{
-			offers: storedFlightOffers,
-		}
+// setCursor selects row n and scrolls just enough to keep it in view. The
+// table is only handed the rows in view, so it never scrolls itself and
+// the row under a line of its body is always top plus that line.
+func (resultsState *ResultsState) setCursor(n int) {
+	rows := len(resultsState.formattedRows)
+	height := max(resultsState.table.Height(), 1)
+	resultsState.cursor = max(min(n, rows-1), 0)
+	if resultsState.cursor < resultsState.top {
+		resultsState.top = resultsState.cursor
+	}
+	if resultsState.cursor >= resultsState.top+height {
+		resultsState.top = resultsState.cursor - height + 1
+	}
+	resultsState.top = max(min(resultsState.top, rows-height), 0)
+
+	end := min(resultsState.top+height, rows)
+	resultsState.table.SetRows(resultsState.formattedRows[resultsState.top:end])
+	resultsState.table.SetCursor(resultsState.cursor - resultsState.top)
+}
+
+// move handles the navigation keys, and reports whether msg was one.
+func (resultsState *ResultsState) move(msg tea.KeyMsg) bool {
+	keys := resultsState.keys
+	page := max(resultsState.table.Height(), 1)
+	switch {
+	case key.Matches(msg, keys.Up):
+		resultsState.setCursor(resultsState.cursor - 1)
+	case key.Matches(msg, keys.Down):
+		resultsState.setCursor(resultsState.cursor + 1)
+	case key.Matches(msg, keys.PageUp):
+		resultsState.setCursor(resultsState.cursor - page)
+	case key.Matches(msg, keys.PageDown):
+		resultsState.setCursor(resultsState.cursor + page)
+	case key.Matches(msg, keys.Top):
+		resultsState.setCursor(0)
+	case key.Matches(msg, keys.Bottom):
+		resultsState.setCursor(len(resultsState.formattedRows) - 1)
+	default:
+		return false
+	}
+	return true
+}
 
-		storedScreenResults.buildTable(100)
-		return storedScreenResults
+// setOffers replaces the search results and rebuilds the table.
+func (resultsState *ResultsState) setOffers(offers []types.FlightOffer) {
+	resultsState.allOffers = offers
+	resultsState.applyFilter()
+}
+
+// applyFilter picks the offers to show from allOffers and rebuilds the
+// table. Offers without segments are never shown.
+func (resultsState *ResultsState) applyFilter() {
+	shown := make([]types.FlightOffer, 0, len(resultsState.allOffers))
+	for _, offer := range resultsState.allOffers {
+		if len(offer.Segments) == 0 {
+			continue
+		}
+		if resultsState.directOnly && len(offer.Segments) > 1 {
+			continue
+		}
+		if resultsState.lowCO2Only && !lowEmissions(offer) {
+			continue
+		}
+		shown = append(shown, offer)
 	}
+	resultsState.offers = shown
+	resultsState.buildTable()
+}
 
+func (resultsState *ResultsState) toggleDirectOnly() {
+	resultsState.directOnly = !resultsState.directOnly
+	resultsState.applyFilter()
+}
+
+func (resultsState *ResultsState) toggleLowCO2Only() {
+	resultsState.lowCO2Only = !resultsState.lowCO2Only
+	resultsState.applyFilter()
+}
+
+func newResultsState(keys resultsKeyMap) ResultsState {
 	t := table.New(
 		table.WithColumns([]table.Column{}),
 	)
-	return ResultsState{table: t}
+	return ResultsState{table: t, keys: keys, starred: map[string]bool{}, sortColumn: -1}
 }
 
 func updateResults(m Model, msg tea.Msg) (tea.Model, tea.Cmd) {
-	var cmd tea.Cmd
-
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case " ":
+		switch {
+		case key.Matches(msg, m.keys.Results.Star):
 			return markRowAsStarredCmd(m)
+		case key.Matches(msg, m.keys.Results.Mark):
+			if err := m.screenResults.toggleCompared(); err != nil {
+				return m, m.setStatus(err.Error())
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.Results.Compare):
+			return m, m.openCompare()
 		}
 	}
 
-	beforeCursor := m.screenResults.table.Cursor()
-	m.screenResults.table, cmd = m.screenResults.table.Update(msg)
-	afterCursor := m.screenResults.table.Cursor()
-
-	if beforeCursor != afterCursor {
-		return m, getFlightDetailsCmd(m)
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		before := m.screenResults.cursor
+		if m.screenResults.move(msg) && m.screenResults.cursor != before {
+			return m, getFlightDetailsCmd(m)
+		}
 	}
-
-	return m, cmd
+	return m, nil
 }
 
 func viewResults(m Model) string {
 	s := ""
-	s += lipgloss.NewStyle().Foreground(styles.NeonPurple).Bold(true).Width(30).Render("[Results]")
+	s += styles.Active().Title().Width(30).Render("[Results]")
+	if m.screenResults.directOnly {
+		s += lipgloss.NewStyle().Foreground(styles.Active().Muted).Render(" nonstop only")
+	}
+	if m.screenResults.lowCO2Only {
+		s += lipgloss.NewStyle().Foreground(styles.Active().Muted).Render(" typical CO2 or less")
+	}
 	s += "\n"
 	s += m.screenResults.table.View()
 	return s
 }
 
 func markRowAsStarredCmd(model Model) (Model, tea.Cmd) {
-	idx := model.screenResults.table.Cursor()
+	idx := model.screenResults.cursor
 	if idx < 0 || idx >= len(model.screenResults.formattedRows) {
 		return model, nil
 	}
-	row := model.screenResults.formattedRows[idx]
-	spreadOfUnchangeValues := row[1:]
-	var modifiedFormattedRow []string
-	if row[0] == "●" {
-		modifiedFormattedRow = append(table.Row{""}, spreadOfUnchangeValues...)
+	offerID := model.screenResults.offers[idx].OfferID
+	if model.screenResults.starred[offerID] {
+		delete(model.screenResults.starred, offerID)
 	} else {
-		modifiedFormattedRow = append(table.Row{"●"}, spreadOfUnchangeValues...)
+		model.screenResults.starred[offerID] = true
 	}
-	model.screenResults.formattedRows[idx] = modifiedFormattedRow
-	model.screenResults.table.SetRows(model.screenResults.formattedRows)
+	model.screenResults.refreshMarkers()
 
 	return model, nil
 }
 
+// toggleCompared marks or unmarks the selected offer for comparison.
+func (resultsState *ResultsState) toggleCompared() error {
+	idx := resultsState.cursor
+	if idx < 0 || idx >= len(resultsState.offers) {
+		return nil
+	}
+	offerID := resultsState.offers[idx].OfferID
+	if i := slices.Index(resultsState.compared, offerID); i >= 0 {
+		resultsState.compared = slices.Delete(resultsState.compared, i, i+1)
+	} else {
+		if len(resultsState.compared) == maxCompared {
+			return fmt.Errorf("at most %d offers can be compared", maxCompared)
+		}
+		resultsState.compared = append(resultsState.compared, offerID)
+	}
+	resultsState.refreshMarkers()
+	return nil
+}
+
+// comparedOffers returns the offers marked for comparison, in slot order.
+func (resultsState *ResultsState) comparedOffers() []types.FlightOffer {
+	var out []types.FlightOffer
+	for _, id := range resultsState.compared {
+		for _, offer := range resultsState.allOffers {
+			if offer.OfferID == id {
+				out = append(out, offer)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// marker is the text of the first column: a star for starred offers and
+// the comparison slot for offers marked for comparison.
+func (resultsState *ResultsState) marker(offerID string) string {
+	star := ""
+	if resultsState.starred[offerID] {
+		star = "●"
+	}
+	if i := slices.Index(resultsState.compared, offerID); i >= 0 {
+		return fmt.Sprintf("%-1s%d", star, i+1)
+	}
+	return star
+}
+
+// refreshConnections redoes the connection warnings after the layover rules
+// changed.
+func (resultsState *ResultsState) refreshConnections() {
+	for i, offer := range resultsState.offers {
+		if i < len(resultsState.formattedRows) {
+			resultsState.formattedRows[i][colConnections] = layoverRules.connectionSummary(offer)
+		}
+	}
+	resultsState.setCursor(resultsState.cursor)
+}
+
+func (resultsState *ResultsState) refreshMarkers() {
+	for i, offer := range resultsState.offers {
+		if i < len(resultsState.formattedRows) {
+			resultsState.formattedRows[i][colStarred] = resultsState.marker(offer.OfferID)
+		}
+	}
+	resultsState.setCursor(resultsState.cursor)
+}
+
 func getFlightDetailsCmd(model Model) tea.Cmd {
 	return func() tea.Msg {
-		idx := model.screenResults.table.Cursor()
+		idx := model.screenResults.cursor
 		if idx < 0 || idx >= len(model.screenResults.offers) {
 			return nil
 		}
@@ -165,3 +353,158 @@ func getFlightDetailsCmd(model Model) tea.Cmd {
 		return flightDetailsSelectedMsg{offer: offer}
 	}
 }
+
+// sortBy orders the offers by a table column. Sorting by the column that is
+// already active flips the direction. The selected offer stays selected.
+func (resultsState *ResultsState) sortBy(column int) {
+	if column == resultsState.sortColumn {
+		resultsState.sortDesc = !resultsState.sortDesc
+	} else {
+		resultsState.sortColumn = column
+		resultsState.sortDesc = false
+	}
+	if len(resultsState.offers) != len(resultsState.formattedRows) {
+		return
+	}
+
+	order := make([]int, len(resultsState.offers))
+	for i := range order {
+		order[i] = i
+	}
+	currency := activeProfile().Currency
+	sort.SliceStable(order, func(a, b int) bool {
+		// prices in another currency go last in either direction
+		if c := resultsState.compareCurrencies(order[a], order[b], column, currency); c != 0 {
+			return c < 0
+		}
+		cmp := resultsState.compareRows(order[a], order[b], column)
+		if resultsState.sortDesc {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+
+	selected := resultsState.cursor
+	offers := make([]types.FlightOffer, len(order))
+	rows := make([]table.Row, len(order))
+	newCursor := 0
+	for to, from := range order {
+		offers[to] = resultsState.offers[from]
+		rows[to] = resultsState.formattedRows[from]
+		if from == selected {
+			newCursor = to
+		}
+	}
+	resultsState.offers = offers
+	resultsState.formattedRows = rows
+	resultsState.setCursor(newCursor)
+	resultsState.setTableWidth(resultsState.width)
+}
+
+// sortPrice is the amount a price column sorts by, and false for other
+// columns.
+func sortPrice(o types.FlightOffer, column int) (types.Money, bool) {
+	switch column {
+	case colPrice:
+		return o.Price(), true
+	case colEstimatedTotal:
+		return o.EstimatedTotal(), true
+	default:
+		return types.Money{}, false
+	}
+}
+
+// compareCurrencies orders offers whose prices in a price column are in
+// different currencies: the display currency first, then offers that
+// couldn't be converted, grouped by currency. It is 0 otherwise, so
+// compareRows only ever compares amounts in one currency.
+func (resultsState *ResultsState) compareCurrencies(a, b, column int, currency string) int {
+	pa, ok := sortPrice(resultsState.offers[a], column)
+	if !ok {
+		return 0
+	}
+	pb, _ := sortPrice(resultsState.offers[b], column)
+	switch {
+	case pa.Currency == pb.Currency:
+		return 0
+	case pa.Currency == currency:
+		return -1
+	case pb.Currency == currency:
+		return 1
+	default:
+		return strings.Compare(pa.Currency, pb.Currency)
+	}
+}
+
+func (resultsState *ResultsState) compareRows(a, b, column int) int {
+	oa, ob := resultsState.offers[a], resultsState.offers[b]
+	switch column {
+	case colStarred:
+		// starred offers first
+		sa, sb := resultsState.starred[oa.OfferID], resultsState.starred[ob.OfferID]
+		switch {
+		case sa == sb:
+			return 0
+		case sa:
+			return -1
+		default:
+			return 1
+		}
+	case colDeparture:
+		return oa.Segments[0].DepartAt.Compare(ob.Segments[0].DepartAt)
+	case colArrival:
+		return oa.Segments[len(oa.Segments)-1].ArriveAt.Compare(ob.Segments[len(ob.Segments)-1].ArriveAt)
+	case colDuration:
+		return cmp.Compare(totalDuration(oa), totalDuration(ob))
+	case colPrice:
+		return cmp.Compare(oa.Price().Amount, ob.Price().Amount)
+	case colEstimatedTotal:
+		return cmp.Compare(oa.EstimatedTotal().Amount, ob.EstimatedTotal().Amount)
+	case colEmissions:
+		// offers without emissions last
+		switch {
+		case oa.Emissions == nil && ob.Emissions == nil:
+			return 0
+		case oa.Emissions == nil:
+			return 1
+		case ob.Emissions == nil:
+			return -1
+		}
+		return cmp.Compare(oa.Emissions.Grams, ob.Emissions.Grams)
+	case colConnections:
+		return cmp.Compare(layoverRules.connectionWarningCount(oa), layoverRules.connectionWarningCount(ob))
+	default:
+		return strings.Compare(resultsState.formattedRows[a][column], resultsState.formattedRows[b][column])
+	}
+}
+
+func totalDuration(offer types.FlightOffer) time.Duration {
+	if len(offer.Segments) == 0 {
+		return 0
+	}
+	return offer.Segments[len(offer.Segments)-1].ArriveAt.Sub(offer.Segments[0].DepartAt)
+}
+
+// rowAtLine maps a line of the visible table body to a row index.
+func (resultsState *ResultsState) rowAtLine(line int) (int, bool) {
+	row := resultsState.top + line
+	if line < 0 || line >= resultsState.table.Height() || row >= len(resultsState.formattedRows) {
+		return 0, false
+	}
+	return row, true
+}
+
+// columnAt maps an x offset inside the table to a column index.
+func (resultsState *ResultsState) columnAt(x int) (int, bool) {
+	edge := 0
+	for i, col := range resultsState.table.Columns() {
+		if col.Width <= 0 {
+			continue
+		}
+		edge += col.Width + 2 // cell padding
+		if x < edge {
+			return i, true
+		}
+	}
+	return 0, false
+}
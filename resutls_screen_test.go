@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/bubbles/table"
+)
+
+func TestResultsScroll(t *testing.T) {
+	tests := []struct {
+		name      string
+		moves     []int // cursor positions, in order
+		cursor    int
+		top       int
+		lineThree int // the row under the fourth line of the body
+	}{
+		{"first page", []int{3}, 3, 0, 3},
+		{"down past the bottom", []int{4, 5}, 5, 1, 4},
+		{"back up inside the page", []int{7, 5}, 5, 3, 6},
+		{"up past the top", []int{9, 1}, 1, 1, 4},
+		{"clamped to the last row", []int{100}, 11, 7, 10},
+		{"clamped to the first row", []int{6, -5}, 0, 0, 3},
+	}
+	for _, tt := range tests {
+		var r ResultsState
+		r.formattedRows = make([]table.Row, 12)
+		r.table = table.New(table.WithColumns([]table.Column{{Title: "Route", Width: 8}}))
+		r.table.SetHeight(6) // five rows under the header
+		for _, n := range tt.moves {
+			r.setCursor(n)
+		}
+		if r.cursor != tt.cursor || r.top != tt.top {
+			t.Errorf("%s: cursor %d, top %d, want %d, %d", tt.name, r.cursor, r.top, tt.cursor, tt.top)
+		}
+		if r.table.Cursor() != r.cursor-r.top || len(r.table.Rows()) != 5 {
+			t.Errorf("%s: table cursor %d of %d rows", tt.name, r.table.Cursor(), len(r.table.Rows()))
+		}
+		if row, ok := r.rowAtLine(3); !ok || row != tt.lineThree {
+			t.Errorf("%s: rowAtLine(3) = %d, %v, want %d", tt.name, row, ok, tt.lineThree)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const savedSearchesKey = "savedSearches"
+
+// searchQuery is everything needed to run a search.
+type searchQuery struct {
+	Origin        string `json:"origin"`
+	Destination   string `json:"destination"`
+	DepartureDate string `json:"departure_date"`
+	Provider      string `json:"provider"`
+}
+
+// savedSearch is a search form saved under a name, e.g.
+// "Home→Copenhagen, Thu departures".
+type savedSearch struct {
+	Name  string      `json:"name"`
+	Query searchQuery `json:"query"`
+}
+
+// describe summarizes a query, e.g. "YYZ→CPH 2026-03-12".
+func (q searchQuery) describe() string {
+	s := fmt.Sprintf("%s→%s", emptyDash(q.Origin), emptyDash(q.Destination))
+	if q.DepartureDate != "" {
+		s += " " + q.DepartureDate
+	}
+	return s
+}
+
+func (s savedSearch) label() string { return s.Name }
+func (s savedSearch) hint() string  { return s.Query.describe() }
+
+func (s savedSearch) FilterValue() string { return s.Name }
+
+func loadSavedSearches() ([]savedSearch, error) {
+	return FetchStoredData[[]savedSearch](savedSearchesKey)
+}
+
+// storeSavedSearch adds a saved search, replacing any with the same name.
+func storeSavedSearch(s savedSearch) error {
+	s.Name = strings.TrimSpace(s.Name)
+	if s.Name == "" {
+		return fmt.Errorf("saved search needs a name")
+	}
+	return UpdateStoredData(savedSearchesKey, func(saved *[]savedSearch) error {
+		*saved = slices.DeleteFunc(*saved, func(other savedSearch) bool {
+			return strings.EqualFold(other.Name, s.Name)
+		})
+		*saved = append(*saved, s)
+		slices.SortFunc(*saved, func(a, b savedSearch) int {
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		})
+		return nil
+	})
+}
+
+func deleteSavedSearch(name string) error {
+	return UpdateStoredData(savedSearchesKey, func(saved *[]savedSearch) error {
+		*saved = slices.DeleteFunc(*saved, func(s savedSearch) bool {
+			return strings.EqualFold(s.Name, name)
+		})
+		return nil
+	})
+}
+
+// findSavedSearch looks a saved search up by name or by its slug, so
+// "Home CPH" can be run as --saved home-cph.
+func findSavedSearch(name string) (savedSearch, error) {
+	saved, err := loadSavedSearches()
+	if err != nil {
+		return savedSearch{}, err
+	}
+	for _, s := range saved {
+		if strings.EqualFold(s.Name, name) || slugify(s.Name) == slugify(name) {
+			return s, nil
+		}
+	}
+	return savedSearch{}, fmt.Errorf("no saved search named %q", name)
+}
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+func slugify(name string) string {
+	return strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
@@ -1,24 +1,50 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/justinm35/flyctl/providers/amadeus"
 	rapidgoogleflights "github.com/justinm35/flyctl/providers/rapid_google_flights"
 	"github.com/justinm35/flyctl/styles"
+	"github.com/justinm35/flyctl/types"
+	"github.com/spf13/viper"
 )
 
 type SearchState struct {
-	inputs  []textinput.Model
-	loading bool
-	spinner spinner.Model
-	focus   int
-	err     string
+	inputs    []textinput.Model
+	loading   bool
+	spinner   spinner.Model
+	focus     int
+	provider  string
+	mode      searchMode
+	nameInput textinput.Model
+	saved     list.Model
+	err       string
 }
 
+// searchMode is what the search pane is showing.
+type searchMode int
+
+const (
+	searchForm     searchMode = iota
+	searchNaming              // asking for a name to save the form under
+	searchBrowsing            // picking a saved search
+)
+
+// searchProviders lists the providers a search can be sent to.
+var searchProviders = []string{rapidgoogleflights.ProviderName, amadeus.ProviderName}
+
 func newSearchState() SearchState {
 	makeInput := func(placeholder string, charLimit int) textinput.Model {
 		ti := textinput.New()
@@ -37,66 +63,92 @@ func newSearchState() SearchState {
 
 	sp := spinner.New()
 	sp.Spinner = spinner.Dot
-	sp.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
 
 	inputs[0].Focus()
 
+	nameInput := makeInput("e.g. Home→Copenhagen, Thu departures", 60)
+	nameInput.Prompt = "Save as: "
+
+	saved := list.New(nil, lineDelegate{}, 40, 8)
+	saved.SetShowTitle(false)
+	saved.SetShowStatusBar(false)
+	saved.SetShowHelp(false)
+	saved.SetFilteringEnabled(false)
+	saved.DisableQuitKeybindings()
+
+	profile := activeProfile()
+	inputs[0].SetValue(profile.Home)
+	provider := profile.provider()
+	if !slices.Contains(searchProviders, provider) {
+		provider = rapidgoogleflights.ProviderName
+	}
+
 	return SearchState{
-		inputs:  inputs,
-		loading: false,
-		spinner: sp,
-		focus:   0,
+		inputs:    inputs,
+		loading:   false,
+		spinner:   sp,
+		focus:     0,
+		provider:  provider,
+		nameInput: nameInput,
+		saved:     saved,
 	}
 
 }
 
 func (s SearchState) initCmd() tea.Cmd { return textinput.Blink }
 
+// setFocus moves the cursor to input i, wrapping around at either end.
+func (s *SearchState) setFocus(i int) {
+	if i < 0 {
+		i = len(s.inputs) - 1
+	} else if i >= len(s.inputs) {
+		i = 0
+	}
+	s.focus = i
+
+	for i := range s.inputs {
+		if i == s.focus {
+			s.inputs[i].Focus()
+			s.inputs[i].PromptStyle = s.inputs[i].PromptStyle.Bold(true)
+			s.inputs[i].TextStyle = s.inputs[i].TextStyle.Bold(true)
+		} else {
+			s.inputs[i].Blur()
+			s.inputs[i].PromptStyle = s.inputs[i].PromptStyle.Bold(false)
+			s.inputs[i].TextStyle = s.inputs[i].TextStyle.Bold(false)
+		}
+	}
+}
+
 func updateSearch(m Model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch m.screenSearch.mode {
+	case searchNaming:
+		return updateSearchNaming(m, msg)
+	case searchBrowsing:
+		return updateSearchBrowsing(m, msg)
+	}
+
 	switch msg := msg.(type) {
-	case errMsg:
-		m.screenSearch.loading = false
-		m.screenSearch.err = msg.err.Error()
-		return m, nil
-	case spinner.TickMsg:
-		if m.screenSearch.loading {
-			var cmd tea.Cmd
-			m.screenSearch.spinner, cmd = m.screenSearch.spinner.Update(msg)
-			return m, cmd
-		}
-		return m, nil
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "tab", "shift+tab", "up", "down":
-			if msg.String() == "shift+tab" || msg.String() == "up" {
+		switch {
+		case key.Matches(msg, m.keys.Search.Save):
+			return m, m.screenSearch.startNaming()
+		case key.Matches(msg, m.keys.Search.OpenSaved):
+			if err := m.screenSearch.startBrowsing(); err != nil {
+				return m, m.setStatus(err.Error())
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.Search.NextField, m.keys.Search.PrevField):
+			if key.Matches(msg, m.keys.Search.PrevField) {
 				m.screenSearch.focus--
 			} else {
 				m.screenSearch.focus++
 			}
 
-			if m.screenSearch.focus < 0 {
-				m.screenSearch.focus = len(m.screenSearch.inputs) - 1
-			} else if m.screenSearch.focus >= len(m.screenSearch.inputs) {
-				m.screenSearch.focus = 0
-			}
-
-			for i := range m.screenSearch.inputs {
-				if i == m.screenSearch.focus {
-					m.screenSearch.inputs[i].Focus()
-					m.screenSearch.inputs[i].PromptStyle = m.screenSearch.inputs[i].PromptStyle.Bold(true)
-					m.screenSearch.inputs[i].TextStyle = m.screenSearch.inputs[i].TextStyle.Bold(true)
-				} else {
-					m.screenSearch.inputs[i].Blur()
-					m.screenSearch.inputs[i].PromptStyle = m.screenSearch.inputs[i].PromptStyle.Bold(false)
-					m.screenSearch.inputs[i].TextStyle = m.screenSearch.inputs[i].TextStyle.Bold(false)
-				}
-			}
-
+			m.screenSearch.setFocus(m.screenSearch.focus)
 			return m, nil
-		case "enter":
+		case key.Matches(msg, m.keys.Search.Submit):
 			// TODO: Validate input
-			m.screenSearch.loading = true
-			return m, tea.Batch(m.screenSearch.spinner.Tick, getSearchResultsCmd(m))
+			return m, m.startSearch()
 		}
 	}
 	// Let the focused input handle the message
@@ -107,50 +159,318 @@ func updateSearch(m Model, msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func viewSeach(m Model) string {
-	labels := []string{"From", "To", "Depart", "Return"}
-	s := fmt.Sprintf(
-		`%s
-
-%s  %s
-%s %s
-
-%s
-%s
-`,
-		lipgloss.NewStyle().Foreground(styles.NeonPurple).Bold(true).Width(30).Render("[Flight Search]"),
-		lipgloss.NewStyle().Foreground(styles.HotPink).Width(30).Render(labels[0]),
-		lipgloss.NewStyle().Foreground(styles.HotPink).Width(30).Render(labels[1]),
-		m.screenSearch.inputs[0].View(),
-		m.screenSearch.inputs[1].View(),
-		lipgloss.NewStyle().Foreground(styles.HotPink).Width(30).Render(labels[2]),
-		m.screenSearch.inputs[2].View(),
-	) + "\n"
-
-	if m.screenSearch.loading {
-		s += fmt.Sprintf("%s Searcing flights...", m.screenSearch.spinner.View())
+	if m.screenSearch.mode == searchBrowsing {
+		return viewSavedSearches(m)
+	}
+	return searchFormLayout(m).String()
+}
+
+// searchFormLayout lays out the search form, also for mapping clicks to inputs.
+func searchFormLayout(m Model) formLayout {
+	theme := styles.Active()
+	label := lipgloss.NewStyle().Foreground(theme.Label).Width(30)
+	muted := lipgloss.NewStyle().Foreground(theme.Muted)
+
+	var f formLayout
+	f.add(theme.Title().Width(30).Render("[Flight Search]"))
+	f.add("")
+	f.add(label.Render("From") + "  " + label.Render("To"))
+	f.addInputs(" ", formInput{0, m.screenSearch.inputs[0].View()}, formInput{1, m.screenSearch.inputs[1].View()})
+	f.add("")
+	f.add(label.Render("Depart"))
+	f.addInputs(" ", formInput{2, m.screenSearch.inputs[2].View()})
+	f.add(muted.Width(30).Render(searchContext(m.screenSearch.provider, activeProfile())))
+	f.add("")
+
+	if m.screenSearch.mode == searchNaming {
+		f.add(m.screenSearch.nameInput.View())
+		f.add(muted.Render("save (enter) • cancel (esc)"))
+	} else if m.screenSearch.loading {
+		f.add(fmt.Sprintf("%s Searcing flights...", m.screenSearch.spinner.View()))
 	} else if m.screenSearch.err != "" {
-		s += fmt.Sprintf("\n Following error occured while fetching flights %s", m.screenSearch.err)
-		s += lipgloss.NewStyle().Foreground(styles.MutedGray).Width(30).Render("Search (enter)")
+		f.add(fmt.Sprintf("\n Following error occured while fetching flights %s", m.screenSearch.err) +
+			muted.Width(30).Render("Search (enter)"))
 	} else {
-		s += lipgloss.NewStyle().Foreground(styles.MutedGray).Width(30).Render("Search (enter)")
+		f.add(muted.Width(30).Render("Search (enter)"))
 	}
-	return s
+	return f
 }
 
 func getSearchResultsCmd(model Model) tea.Cmd {
+	query := model.screenSearch.query()
+	tabID := model.activeTabID()
+	settings := newSearchSettings(query.Provider)
+
 	return func() tea.Msg {
-		input := rapidgoogleflights.GetSearchResultsInput{
-			SourceIata:      model.screenSearch.inputs[0].Value(),
-			DestinationIata: model.screenSearch.inputs[1].Value(),
-			DepartureDate:   model.screenSearch.inputs[2].Value(),
-			Adults:          1,
+		offers, err := runSearch(context.Background(), query, settings)
+		if err != nil {
+			return errMsg{tabID, err}
+		}
+
+		return searchResultsMsg{tabID: tabID, query: query, offers: offers}
+	}
+
+}
+
+// startSearch runs the search in the form of the active tab.
+func (m *Model) startSearch() tea.Cmd {
+	if problems := unsetCredentials(viper.GetViper(), m.screenSearch.provider); len(problems) > 0 {
+		cmd := m.openSetup()
+		m.setup.err = joinProblems(problems).Error()
+		return cmd
+	}
+	m.screenSearch.loading = true
+	m.screenSearch.err = ""
+	return tea.Batch(m.screenSearch.spinner.Tick, getSearchResultsCmd(*m))
+}
+
+// searchSettings is everything a search reads from the config. It is taken
+// before the search starts because viper is not safe for concurrent use,
+// and the config can be reloaded while a search runs in its tea.Cmd.
+type searchSettings struct {
+	profile       Profile
+	exchangeRates ExchangeRates
+	bagFees       map[string]BagFee
+	credentials   map[string]secretSource
+}
+
+func newSearchSettings(provider string) searchSettings {
+	cfg := currentConfig()
+	s := searchSettings{
+		profile:       cfg.resolveProfile(cfg.Profile),
+		exchangeRates: cfg.ExchangeRates,
+		bagFees:       bagFees(cfg.BagFees),
+		credentials:   map[string]secretSource{},
+	}
+	for _, key := range providerCredentials[provider].keys {
+		s.credentials[key] = secretSourceFor(viper.GetViper(), key)
+	}
+	return s
+}
+
+// secret resolves one of the provider's credentials.
+func (s searchSettings) secret(key string) (string, error) {
+	return s.credentials[key].resolve()
+}
+
+// runSearch sends a query to its provider.
+func runSearch(ctx context.Context, query searchQuery, settings searchSettings) ([]types.FlightOffer, error) {
+	logger := slog.With("provider", query.Provider, "query", query.describe())
+	logger.Debug("search started")
+	start := time.Now()
+	profile := settings.profile
+	offers, err := searchProvider(ctx, query, settings)
+	if err != nil {
+		logger.Error("search failed", "duration", time.Since(start), "err", err)
+		return nil, err
+	}
+	found := len(offers)
+	offers = profile.filterOffers(offers)
+	rates := newLazyRates(ctx, settings.exchangeRates)
+	offers = convertOffers(offers, profile.Currency, rates)
+	offers = estimateCosts(offers, profile, settings.bagFees, rates)
+	offers = estimateEmissions(offers, profile.Cabin)
+	logger.Info("search finished", "duration", time.Since(start), "offers", len(offers), "excluded", found-len(offers))
+	return offers, nil
+}
+
+func searchProvider(ctx context.Context, query searchQuery, settings searchSettings) ([]types.FlightOffer, error) {
+	profile := settings.profile
+	switch query.Provider {
+	case amadeus.ProviderName:
+		departAt, err := time.Parse("2006-01-02", query.DepartureDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid departure date %q: %w", query.DepartureDate, err)
+		}
+		var creds amadeus.Credentials
+		if creds.APIKey, err = settings.secret("amadeus_api_key"); err != nil {
+			return nil, err
 		}
-		offers, err := rapidgoogleflights.SearchFlights(input)
+		if creds.APISecret, err = settings.secret("amadeus_api_secret"); err != nil {
+			return nil, err
+		}
+		return amadeus.SearchFlights(ctx, creds, types.SearchRequest{
+			Origin:      query.Origin,
+			Destination: query.Destination,
+			DepartDate:  departAt,
+			Adults:      profile.Passengers.Adults,
+			Children:    profile.Passengers.Children,
+			Infants:     profile.Passengers.Infants,
+			Cabin:       profile.Cabin,
+			MaxResults:  50,
+			Currency:    profile.Currency,
+		})
+	default:
+		apiKey, err := settings.secret("rapid_google_api_key")
 		if err != nil {
-			return errMsg{err}
+			return nil, err
+		}
+		return rapidgoogleflights.SearchFlights(rapidgoogleflights.GetSearchResultsInput{
+			APIKey:          apiKey,
+			SourceIata:      query.Origin,
+			DestinationIata: query.Destination,
+			DepartureDate:   query.DepartureDate,
+			Adults:          profile.Passengers.Adults,
+			Children:        profile.Passengers.Children,
+			Infants:         profile.Passengers.Infants,
+			Cabin:           profile.Cabin,
+			Currency:        profile.Currency,
+		})
+	}
+}
+
+// query reads the search form.
+func (s SearchState) query() searchQuery {
+	return searchQuery{
+		Origin:        strings.ToUpper(strings.TrimSpace(s.inputs[0].Value())),
+		Destination:   strings.ToUpper(strings.TrimSpace(s.inputs[1].Value())),
+		DepartureDate: strings.TrimSpace(s.inputs[2].Value()),
+		Provider:      s.provider,
+	}
+}
+
+// apply fills the search form in from a query.
+func (s *SearchState) apply(q searchQuery) {
+	s.inputs[0].SetValue(q.Origin)
+	s.inputs[1].SetValue(q.Destination)
+	s.inputs[2].SetValue(q.DepartureDate)
+	if slices.Contains(searchProviders, q.Provider) {
+		s.provider = q.Provider
+	}
+	s.mode = searchForm
+	s.setFocus(0)
+}
+
+func (s *SearchState) startNaming() tea.Cmd {
+	s.mode = searchNaming
+	s.nameInput.Reset()
+	s.inputs[s.focus].Blur()
+	return s.nameInput.Focus()
+}
+
+func (s *SearchState) startBrowsing() error {
+	s.mode = searchBrowsing
+	return s.refreshSaved()
+}
+
+func (s *SearchState) refreshSaved() error {
+	saved, err := loadSavedSearches()
+	var items []list.Item
+	for _, entry := range saved {
+		items = append(items, entry)
+	}
+	s.saved.SetItems(items)
+	return err
+}
+
+func (s *SearchState) closeSavedModes() {
+	s.mode = searchForm
+	s.nameInput.Blur()
+	s.setFocus(s.focus)
+}
+
+func updateSearchNaming(m Model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	if km, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(km, m.keys.Search.Cancel):
+			m.screenSearch.closeSavedModes()
+			return m, nil
+		case km.Type == tea.KeyEnter:
+			name := m.screenSearch.nameInput.Value()
+			err := storeSavedSearch(savedSearch{Name: name, Query: m.screenSearch.query()})
+			if err != nil {
+				return m, m.setStatus(err.Error())
+			}
+			m.screenSearch.closeSavedModes()
+			return m, m.setStatus(fmt.Sprintf("saved search %q", strings.TrimSpace(name)))
+		}
+	}
+	var cmd tea.Cmd
+	m.screenSearch.nameInput, cmd = m.screenSearch.nameInput.Update(msg)
+	return m, cmd
+}
+
+func updateSearchBrowsing(m Model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	if km, ok := msg.(tea.KeyMsg); ok {
+		selected, hasSelection := m.screenSearch.saved.SelectedItem().(savedSearch)
+		switch {
+		case key.Matches(km, m.keys.Search.Cancel):
+			m.screenSearch.closeSavedModes()
+			return m, nil
+		case key.Matches(km, m.keys.Search.DeleteSaved) && hasSelection:
+			if err := deleteSavedSearch(selected.Name); err != nil {
+				return m, m.setStatus(err.Error())
+			}
+			if err := m.screenSearch.refreshSaved(); err != nil {
+				return m, m.setStatus(err.Error())
+			}
+			return m, m.setStatus(fmt.Sprintf("deleted saved search %q", selected.Name))
+		case key.Matches(km, m.keys.Search.Submit) && hasSelection:
+			m.screenSearch.apply(selected.Query)
+			return m, m.startSearch()
 		}
+	}
+	var cmd tea.Cmd
+	m.screenSearch.saved, cmd = m.screenSearch.saved.Update(msg)
+	return m, cmd
+}
+
+func viewSavedSearches(m Model) string {
+	theme := styles.Active()
+	s := theme.Title().Render("[Saved Searches]") + "\n\n"
+	if len(m.screenSearch.saved.Items()) == 0 {
+		s += lipgloss.NewStyle().Foreground(theme.Muted).Render("Nothing saved yet. Fill in the form and press "+m.keys.Search.Save.Help().Key+".") + "\n\n"
+	} else {
+		s += m.screenSearch.saved.View() + "\n"
+	}
+	s += lipgloss.NewStyle().Foreground(theme.Muted).Render(fmt.Sprintf(
+		"run (%s) • delete (%s) • back (%s)",
+		m.keys.Search.Submit.Help().Key, m.keys.Search.DeleteSaved.Help().Key, m.keys.Search.Cancel.Help().Key,
+	))
+	return s
+}
 
-		return searchResultsMsg{offers: offers}
+// swapRoute exchanges the origin and destination inputs.
+func (s *SearchState) swapRoute() {
+	from, to := s.inputs[0].Value(), s.inputs[1].Value()
+	s.inputs[0].SetValue(to)
+	s.inputs[1].SetValue(from)
+}
+
+// reset clears the form, keeping the profile's home airport as origin, and
+// puts the cursor back on the first input.
+func (s *SearchState) reset() {
+	for i := range s.inputs {
+		s.inputs[i].Reset()
 	}
+	s.inputs[0].SetValue(activeProfile().Home)
+	s.err = ""
+	s.setFocus(0)
+}
 
+// searchContext is the line under the form saying where and for whom a
+// search runs, e.g. "via amadeus · alex: 2 adults, 1 child, business".
+func searchContext(provider string, p Profile) string {
+	travelers := plural(p.Passengers.Adults, "adult")
+	if p.Passengers.Children > 0 {
+		travelers += ", " + plural(p.Passengers.Children, "child")
+	}
+	if p.Passengers.Infants > 0 {
+		travelers += ", " + plural(p.Passengers.Infants, "infant")
+	}
+	s := "via " + provider + " · "
+	if p.Name != "" {
+		s += p.Name + ": "
+	}
+	return s + travelers + ", " + strings.ReplaceAll(p.Cabin, "_", " ")
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	if noun == "child" {
+		return fmt.Sprintf("%d children", n)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
 }
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// secretCommandTimeout bounds a credential helper, which may be waiting on
+// an unlock prompt.
+const secretCommandTimeout = 30 * time.Second
+
+var (
+	secretCacheMu sync.Mutex
+	// secretCache holds helper output by command line, so a helper that
+	// prompts to unlock runs once per session rather than once per search.
+	secretCache = map[string]string{}
+)
+
+// secretSource is where a credential comes from, copied out of the config
+// so it can be resolved without touching viper, which is not safe for
+// concurrent use, e.g. in the tea.Cmd running a search.
+type secretSource struct {
+	key     string
+	value   string
+	file    string
+	command string
+}
+
+func secretSourceFor(v *viper.Viper, key string) secretSource {
+	return secretSource{
+		key:     key,
+		value:   strings.TrimSpace(v.GetString(key)),
+		file:    v.GetString(key + "_file"),
+		command: v.GetString(key + "_command"),
+	}
+}
+
+// configured reports whether the credential has a value, file or helper
+// command, without reading the file or running the helper. The placeholder
+// older versions wrote counts as unset.
+func (s secretSource) configured() bool {
+	return s.value != "" && s.value != placeholderSecret || s.file != "" || s.command != ""
+}
+
+// resolve finds the credential, trying in order:
+//
+//	amadeus_api_secret:          the value itself, or FLYCTL_AMADEUS_API_SECRET
+//	amadeus_api_secret_file:     a file holding it, e.g. ~/.secrets/amadeus
+//	amadeus_api_secret_command:  a command printing it, e.g. pass show flyctl/amadeus
+//
+// The _file and _command keys can come from the environment as well.
+func (s secretSource) resolve() (string, error) {
+	if s.value != "" && s.value != placeholderSecret {
+		return s.value, nil
+	}
+	if s.file != "" {
+		value, err := readSecretFile(s.file)
+		if err != nil {
+			return "", fmt.Errorf("reading %s_file: %w", s.key, err)
+		}
+		return value, nil
+	}
+	if s.command != "" {
+		value, err := runSecretCommand(s.command)
+		if err != nil {
+			return "", fmt.Errorf("running %s_command: %w", s.key, err)
+		}
+		return value, nil
+	}
+	return "", nil
+}
+
+// lookupSecret resolves a credential from a config.
+func lookupSecret(v *viper.Viper, key string) (string, error) {
+	return secretSourceFor(v, key).resolve()
+}
+
+// forgetSecrets drops cached helper output, so changed helpers run again.
+func forgetSecrets() {
+	secretCacheMu.Lock()
+	defer secretCacheMu.Unlock()
+	clear(secretCache)
+}
+
+func readSecretFile(path string) (string, error) {
+	path, err := expandHome(path)
+	if err != nil {
+		return "", err
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimSpace(string(raw))
+	if value == "" {
+		return "", fmt.Errorf("%s is empty", path)
+	}
+	return value, nil
+}
+
+func runSecretCommand(command string) (string, error) {
+	secretCacheMu.Lock()
+	defer secretCacheMu.Unlock()
+	if value, ok := secretCache[command]; ok {
+		return value, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretCommandTimeout)
+	defer cancel()
+	shell, flag := "sh", "-c"
+	if runtime.GOOS == "windows" {
+		shell, flag = "cmd", "/C"
+	}
+	cmd := exec.CommandContext(ctx, shell, flag, command)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+
+	value := strings.TrimSpace(stdout.String())
+	if value == "" {
+		return "", fmt.Errorf("%q printed nothing", command)
+	}
+	secretCache[command] = value
+	return value, nil
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/justinm35/flyctl/providers/amadeus"
+	rapidgoogleflights "github.com/justinm35/flyctl/providers/rapid_google_flights"
+	"github.com/justinm35/flyctl/styles"
+	"github.com/spf13/viper"
+)
+
+// setupField is one input of the setup wizard, backed by a config key.
+type setupField struct {
+	key         string
+	label       string
+	placeholder string
+	secret      bool
+	provider    string // only asked for when this provider is chosen
+}
+
+var setupFields = []setupField{
+	{key: "rapid_google_api_key", label: "RapidAPI key", secret: true, provider: rapidgoogleflights.ProviderName},
+	{key: "amadeus_api_key", label: "Amadeus API key", provider: amadeus.ProviderName},
+	{key: "amadeus_api_secret", label: "Amadeus API secret", secret: true, provider: amadeus.ProviderName},
+	{key: "currency", label: "Currency", placeholder: "e.g. CAD"},
+	{key: "adults", label: "Adults", placeholder: "1-9"},
+}
+
+// SetupState is the first-run wizard that fills in the config. It opens by
+// itself when the chosen provider has no credentials and, like the history,
+// replaces all panes while open.
+type SetupState struct {
+	provider string
+	inputs   []textinput.Model
+	focus    int // 0 is the provider row, i+1 is inputs[i]
+	err      string
+}
+
+func newSetupState() SetupState {
+	s := SetupState{provider: rapidgoogleflights.ProviderName}
+	for _, f := range setupFields {
+		ti := textinput.New()
+		ti.Prompt = ""
+		ti.Placeholder = f.placeholder
+		ti.Width = 50
+		if f.secret {
+			ti.EchoMode = textinput.EchoPassword
+		}
+		s.inputs = append(s.inputs, ti)
+	}
+	return s
+}
+
+// reset fills the wizard in from the config file. Values from FLYCTL_*
+// variables are not shown, so saving can't write them into the file; a
+// credential that comes from the environment, a file or a helper command
+// is left empty, and stays as it is unless something is typed in.
+func (s *SetupState) reset(provider string) {
+	s.provider = provider
+	s.err = ""
+	file := viper.New()
+	file.SetConfigFile(viper.ConfigFileUsed())
+	setConfigDefaults(file)
+	if err := file.ReadInConfig(); err != nil {
+		slog.Warn("read config for setup", "err", err)
+	}
+	for i, f := range setupFields {
+		value := file.GetString(f.key)
+		s.inputs[i].Placeholder = f.placeholder
+		if isCredential(f.key) && (value == placeholderSecret || value != viper.GetString(f.key)) {
+			value = ""
+		}
+		if isCredential(f.key) && value == "" && secretSourceFor(viper.GetViper(), f.key).configured() {
+			s.inputs[i].Placeholder = "set elsewhere; leave empty to keep it"
+		}
+		s.inputs[i].SetValue(value)
+	}
+	s.setFocus(1)
+}
+
+// isCredential reports whether key is a credential of some provider.
+func isCredential(key string) bool {
+	for _, creds := range providerCredentials {
+		if slices.Contains(creds.keys, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// visible reports whether field i applies to the chosen provider.
+func (s SetupState) visible(i int) bool {
+	p := setupFields[i].provider
+	return p == "" || p == s.provider
+}
+
+func (s *SetupState) setFocus(i int) tea.Cmd {
+	s.focus = i
+	for j := range s.inputs {
+		s.inputs[j].Blur()
+	}
+	if i == 0 {
+		return nil
+	}
+	return s.inputs[i-1].Focus()
+}
+
+// move focuses the next visible row in direction dir, wrapping around.
+func (s *SetupState) move(dir int) tea.Cmd {
+	rows := len(s.inputs) + 1
+	i := s.focus
+	for {
+		i = (i + dir + rows) % rows
+		if i == 0 || s.visible(i-1) {
+			return s.setFocus(i)
+		}
+	}
+}
+
+func (s *SetupState) cycleProvider(dir int) {
+	i := slices.Index(searchProviders, s.provider)
+	s.provider = searchProviders[(i+dir+len(searchProviders))%len(searchProviders)]
+}
+
+// values collects the config values to write. Credentials for the chosen
+// provider are required; the other provider's are kept as they are.
+func (s SetupState) values() (map[string]any, error) {
+	values := map[string]any{"provider": s.provider}
+	for i, f := range setupFields {
+		if !s.visible(i) {
+			continue
+		}
+		value := strings.TrimSpace(s.inputs[i].Value())
+		switch f.key {
+		case "currency":
+			values[f.key] = strings.ToUpper(value)
+		case "adults":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("adults must be a number, got %q", value)
+			}
+			values[f.key] = n
+		default:
+			if value == "" && secretSourceFor(viper.GetViper(), f.key).configured() {
+				continue // keep the one from the environment, a file or a helper
+			}
+			if value == "" {
+				return nil, fmt.Errorf("%s is required for %s", f.label, s.provider)
+			}
+			values[f.key] = value
+		}
+	}
+	return values, nil
+}
+
+func (m *Model) openSetup() tea.Cmd {
+	m.setup.reset(m.screenSearch.provider)
+	m.screen = screenSetup
+	m.applyLayout()
+	return textinput.Blink
+}
+
+func updateSetup(m Model, msg tea.Msg) (tea.Model, tea.Cmd) {
+	if km, ok := msg.(tea.KeyMsg); ok {
+		switch {
+		case key.Matches(km, m.keys.Setup.Skip):
+			m.screen = screenSearch
+			m.focusPane(screenSearch)
+			return m, nil
+		case key.Matches(km, m.keys.Setup.NextField):
+			return m, m.setup.move(1)
+		case key.Matches(km, m.keys.Setup.PrevField):
+			return m, m.setup.move(-1)
+		case key.Matches(km, m.keys.Setup.Provider) && m.setup.focus == 0:
+			if km.String() == "left" {
+				m.setup.cycleProvider(-1)
+			} else {
+				m.setup.cycleProvider(1)
+			}
+			return m, nil
+		case key.Matches(km, m.keys.Setup.Save):
+			values, err := m.setup.values()
+			if err == nil {
+				err = setConfigValues(values)
+			}
+			if err != nil {
+				m.setup.err = err.Error()
+				return m, nil
+			}
+			m.screenSearch.provider = m.setup.provider
+			m.screen = screenSearch
+			m.focusPane(screenSearch)
+			return m, m.setStatus("config saved to " + viper.ConfigFileUsed())
+		}
+	}
+	if m.setup.focus == 0 {
+		return m, nil
+	}
+	var cmd tea.Cmd
+	i := m.setup.focus - 1
+	m.setup.inputs[i], cmd = m.setup.inputs[i].Update(msg)
+	return m, cmd
+}
+
+func viewSetup(m Model) string {
+	theme := styles.Active()
+	muted := lipgloss.NewStyle().Foreground(theme.Muted)
+	label := func(text string, focused bool) string {
+		return lipgloss.NewStyle().Foreground(theme.Label).Bold(focused).Width(20).Render(text)
+	}
+
+	s := theme.Title().Render("[Setup]") + "\n\n"
+	s += muted.Width(m.layout.full.innerWidth()).Render("flyctl needs an API key for a flight provider. Everything here is saved to "+viper.ConfigFileUsed()+". To keep keys out of that file, skip this and use FLYCTL_<KEY>, <key>_file or <key>_command instead (see the README).") + "\n\n"
+
+	s += label("Provider", m.setup.focus == 0) + "‹ " + m.setup.provider + " ›\n"
+	s += label("", false) + muted.Render("get credentials at "+providerCredentials[m.setup.provider].signup) + "\n\n"
+	for i, f := range setupFields {
+		if !m.setup.visible(i) {
+			continue
+		}
+		s += label(f.label, m.setup.focus == i+1) + m.setup.inputs[i].View() + "\n"
+	}
+
+	if m.setup.err != "" {
+		s += "\n" + lipgloss.NewStyle().Foreground(theme.Danger).Render(m.setup.err) + "\n"
+	}
+	s += "\n" + muted.Render(fmt.Sprintf(
+		"change provider (%s) • next (%s) • save (%s) • skip (%s)",
+		m.keys.Setup.Provider.Help().Key, m.keys.Setup.NextField.Help().Key, m.keys.Setup.Save.Help().Key, m.keys.Setup.Skip.Help().Key,
+	))
+	return s
+}
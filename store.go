@@ -1,50 +1,143 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justinm35/flyctl/store"
+	"github.com/justinm35/flyctl/types"
 )
 
-func FetchStoredData[T any](key string) T {
-	home, _ := os.UserHomeDir()
-	storeDir := filepath.Join(home, ".config/flyctl/store")
+// openStore opens the local database once per process. The first time it
+// runs, it imports the JSON files older versions kept in store/.
+var openStore = sync.OnceValues(func() (*store.DB, error) {
+	state, err := stateDir()
+	if err != nil {
+		return nil, err
+	}
+	config, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+
+	// the database used to live next to config.yaml
+	path := filepath.Join(state, "flyctl.db")
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		if err := moveIfMissing(filepath.Join(config, "flyctl.db"+suffix), path+suffix); err != nil {
+			return nil, fmt.Errorf("move database to %q: %w", state, err)
+		}
+	}
 
-	file, err := os.ReadFile(fmt.Sprintf("%s/%s.json", storeDir, key))
+	db, err := store.OpenDB(path)
 	if err != nil {
-		log.Printf("Store Error: %s \n", string(err.Error()))
+		return nil, err
 	}
+	if err := importJSONStore(db, filepath.Join(config, "store")); err != nil {
+		slog.Error("import JSON store", "dir", filepath.Join(config, "store"), "err", err)
+	}
+	return db, nil
+})
 
+// FetchStoredData reads the value stored under key. A key that was never
+// stored reads as the zero value.
+func FetchStoredData[T any](key string) (T, error) {
 	var out T
-	json.Unmarshal(file, &out)
-
-	return out
+	s, err := openStore()
+	if err != nil {
+		return out, err
+	}
+	if err := s.Get(key, &out); err != nil && !errors.Is(err, store.ErrNotFound) {
+		return out, err
+	}
+	return out, nil
 }
 
 func StoreData[T any](key string, value T) error {
-	home, _ := os.UserHomeDir()
-	storeDir := filepath.Join(home, "/.config/flyctl/store")
-	storeDirContents, _ := os.ReadDir(storeDir)
-
-	if len(storeDirContents) == 0 {
-		log.Printf("Store Dir did not exist, creating a new one")
-		createStoreErr := os.MkdirAll(storeDir, 0o755)
-		if createStoreErr != nil {
-			log.Printf("Store Error: %s \n", string(createStoreErr.Error()))
-		}
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	return s.Put(key, value)
+}
+
+// UpdateStoredData changes the value under key in place, in one transaction.
+func UpdateStoredData[T any](key string, update func(value *T) error) error {
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	var value T
+	return s.Update(key, &value, func() error { return update(&value) })
+}
+
+// DeleteStoredData removes a key from the store. Removing a key that was
+// never stored is not an error.
+func DeleteStoredData(key string) error {
+	s, err := openStore()
+	if err != nil {
+		return err
 	}
+	return s.Delete(key)
+}
 
-	marhsalledJson, err := json.Marshal(value)
+// Keys used by the JSON store for search history, before it moved to tables.
+const (
+	jsonHistoryKey     = "searchHistory"
+	jsonSnapshotPrefix = "searchSnapshot-"
+)
+
+// importJSONStore copies the JSON files in dir into the database and renames
+// dir to dir.imported, so it only runs once and the old files are kept. A
+// document that couldn't be read is logged and skipped, and can still be
+// found in dir.imported.
+func importJSONStore(db *store.DB, dir string) error {
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	old, err := store.Open(dir)
 	if err != nil {
-		log.Printf("Store Error: %s \n", string(err.Error()))
+		return fmt.Errorf("import %q: %w", dir, err)
 	}
 
-	writeErr := os.WriteFile(fmt.Sprintf("%s/%s.json", storeDir, key), marhsalledJson, 0o755)
-	if writeErr != nil {
-		log.Printf("Store Error: %s \n", string(writeErr.Error()))
+	var history []struct {
+		ID         string      `json:"id"`
+		Query      searchQuery `json:"query"`
+		SearchedAt time.Time   `json:"searched_at"`
+	}
+	if err := old.Get(jsonHistoryKey, &history); err != nil && !errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("import %q: %w", dir, err)
+	}
+	// oldest first, so the new IDs keep the order
+	var past []store.PastSearch
+	for i := len(history) - 1; i >= 0; i-- {
+		entry := history[i]
+		var offers []types.FlightOffer
+		if err := old.Get(jsonSnapshotPrefix+entry.ID, &offers); err != nil && !errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("import %q: %w", dir, err)
+		}
+		past = append(past, store.PastSearch{
+			Search: store.Search{
+				Origin:        entry.Query.Origin,
+				Destination:   entry.Query.Destination,
+				DepartureDate: entry.Query.DepartureDate,
+				Provider:      entry.Query.Provider,
+				SearchedAt:    entry.SearchedAt,
+			},
+			Offers: offers,
+		})
 	}
 
-	return nil
+	err = db.Import(old, past, func(key string) bool {
+		return key == jsonHistoryKey || strings.HasPrefix(key, jsonSnapshotPrefix)
+	})
+	if err != nil {
+		return fmt.Errorf("import %q: %w", dir, err)
+	}
+	return os.Rename(dir, dir+".imported")
 }
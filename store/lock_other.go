@@ -0,0 +1,10 @@
+//go:build !unix && !windows
+
+package store
+
+import "os"
+
+// Platforms without file locks only get atomic writes.
+func lockFile(f *os.File, exclusive bool) error { return nil }
+
+func unlockFile(f *os.File) error { return nil }
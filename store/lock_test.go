@@ -0,0 +1,60 @@
+//go:build unix || windows
+
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSingleWriter(t *testing.T) {
+	tests := []struct {
+		name             string
+		first, second    bool // exclusive
+		secondWaitsFirst bool
+	}{
+		{"writer blocks writer", true, true, true},
+		{"writer blocks reader", true, false, true},
+		{"reader blocks writer", false, true, true},
+		{"readers share", false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// two Store values on one directory lock through separate open
+			// files, like two flyctl processes
+			dir := t.TempDir()
+			a, b := &Store{dir: dir}, &Store{dir: dir}
+
+			holding, release := make(chan struct{}), make(chan struct{})
+			go a.withLock(tt.first, func() error {
+				close(holding)
+				<-release
+				return nil
+			})
+			<-holding
+
+			done := make(chan struct{})
+			go func() {
+				b.withLock(tt.second, func() error { return nil })
+				close(done)
+			}()
+
+			select {
+			case <-done:
+				if tt.secondWaitsFirst {
+					t.Fatal("second lock taken while the first was held")
+				}
+			case <-time.After(100 * time.Millisecond):
+				if !tt.secondWaitsFirst {
+					t.Fatal("second lock waited for the first")
+				}
+			}
+			close(release)
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("second lock not taken after the first was released")
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// migration upgrades the data of one document by one schema version.
+type migration func(key string, data json.RawMessage) (json.RawMessage, error)
+
+// migrations[i] upgrades a document from schema i to schema i+1. Append to
+// the list to change a stored format; never edit a migration once released.
+var migrations = []migration{
+	// 0 → 1: bare JSON from before documents were versioned. The data is
+	// unchanged, it only gains the envelope.
+	func(_ string, data json.RawMessage) (json.RawMessage, error) { return data, nil },
+}
+
+// SchemaVersion is the schema every document is written with.
+var SchemaVersion = len(migrations)
+
+func upgrade(key string, doc document) (document, error) {
+	if doc.Schema > SchemaVersion {
+		return doc, fmt.Errorf("%w: %q has schema %d, this build reads up to %d", ErrNewerSchema, key, doc.Schema, SchemaVersion)
+	}
+	for doc.Schema < SchemaVersion {
+		data, err := migrations[doc.Schema](key, doc.Data)
+		if err != nil {
+			return doc, fmt.Errorf("migrate %q from schema %d: %w", key, doc.Schema, err)
+		}
+		doc = document{Schema: doc.Schema + 1, Data: data}
+	}
+	return doc, nil
+}
+
+// migrate upgrades every document on disk to the current schema. Documents
+// from a newer flyctl are left alone; reading them reports ErrNewerSchema.
+// A document that can't be read or upgraded is logged and left as it is, so
+// one bad file doesn't keep the rest of the store from opening; reading it
+// reports the error.
+func (s *Store) migrate() error {
+	return s.withLock(true, func() error {
+		keys, err := s.keys()
+		if err != nil {
+			return err
+		}
+		var errs []error
+		for _, key := range keys {
+			doc, err := s.read(key)
+			if err != nil {
+				slog.Warn("skip unreadable document", "key", key, "err", err)
+				continue
+			}
+			if doc.Schema >= SchemaVersion {
+				continue
+			}
+			if doc, err = upgrade(key, doc); err != nil {
+				slog.Warn("skip document", "key", key, "err", err)
+				continue
+			}
+			if err := s.write(key, doc); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	})
+}
@@ -0,0 +1,519 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/justinm35/flyctl/types"
+	_ "modernc.org/sqlite" // registers the pure-Go "sqlite" driver
+)
+
+// DB is the SQLite-backed store. It keeps the same key/value API as Store
+// for small documents (settings, saved searches) and real tables for search
+// history so it can be queried.
+type DB struct {
+	db   *sql.DB
+	path string
+}
+
+// schema[i] upgrades the database from user_version i to i+1. Append to the
+// list to change the schema; never edit a step once released.
+var schema = []string{
+	`CREATE TABLE documents (
+		key        TEXT PRIMARY KEY,
+		schema     INTEGER NOT NULL,
+		data       BLOB NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+	CREATE TABLE searches (
+		id             INTEGER PRIMARY KEY AUTOINCREMENT,
+		origin         TEXT NOT NULL,
+		destination    TEXT NOT NULL,
+		departure_date TEXT NOT NULL,
+		provider       TEXT NOT NULL,
+		searched_at    INTEGER NOT NULL
+	);
+	CREATE INDEX searches_by_time ON searches (searched_at);
+	CREATE TABLE offers (
+		id             INTEGER PRIMARY KEY AUTOINCREMENT,
+		search_id      INTEGER NOT NULL REFERENCES searches (id) ON DELETE CASCADE,
+		position       INTEGER NOT NULL,
+		provider       TEXT NOT NULL,
+		offer_id       TEXT NOT NULL,
+		price_amount   INTEGER NOT NULL,
+		price_currency TEXT NOT NULL,
+		data           BLOB NOT NULL
+	);
+	CREATE INDEX offers_by_search ON offers (search_id, position);
+	CREATE TABLE segments (
+		offer_id    INTEGER NOT NULL REFERENCES offers (id) ON DELETE CASCADE,
+		position    INTEGER NOT NULL,
+		origin      TEXT NOT NULL,
+		destination TEXT NOT NULL,
+		depart_at   TEXT NOT NULL,
+		arrive_at   TEXT NOT NULL,
+		carrier     TEXT NOT NULL,
+		flight_no   TEXT NOT NULL,
+		cabin       TEXT NOT NULL,
+		PRIMARY KEY (offer_id, position)
+	);
+	CREATE TABLE price_observations (
+		id             INTEGER PRIMARY KEY AUTOINCREMENT,
+		search_id      INTEGER REFERENCES searches (id) ON DELETE SET NULL,
+		origin         TEXT NOT NULL,
+		destination    TEXT NOT NULL,
+		departure_date TEXT NOT NULL,
+		provider       TEXT NOT NULL,
+		observed_at    INTEGER NOT NULL,
+		amount         INTEGER NOT NULL,
+		currency       TEXT NOT NULL
+	);
+	CREATE INDEX price_observations_by_route ON price_observations (origin, destination, observed_at);`,
+}
+
+// Search is one executed search in the history.
+type Search struct {
+	ID            int64
+	Origin        string
+	Destination   string
+	DepartureDate string
+	Provider      string
+	SearchedAt    time.Time
+	OfferCount    int
+}
+
+// PriceObservation is the cheapest offer one search returned. Observations
+// outlive the search they came from, so price trends survive history pruning.
+type PriceObservation struct {
+	Origin        string
+	Destination   string
+	DepartureDate string
+	Provider      string
+	ObservedAt    time.Time
+	Price         types.Money
+}
+
+// OpenDB opens the database at path, creating it with 0600 permissions if
+// needed, and brings its schema up to date.
+func OpenDB(path string) (*DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), dirMode); err != nil {
+		return nil, fmt.Errorf("create store dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, fileMode)
+	if err != nil {
+		return nil, fmt.Errorf("open %q: %w", path, err)
+	}
+	f.Close()
+
+	dsn := (&url.URL{
+		Scheme: "file",
+		Path:   path,
+		RawQuery: url.Values{
+			"_pragma": {"foreign_keys(1)", "busy_timeout(5000)", "journal_mode(WAL)"},
+			// take the write lock up front so read-modify-write transactions
+			// from two processes wait for each other instead of failing
+			"_txlock": {"immediate"},
+		}.Encode(),
+	}).String()
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open %q: %w", path, err)
+	}
+	s := &DB{db: db, path: path}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *DB) Close() error { return s.db.Close() }
+
+// Path is the database file.
+func (s *DB) Path() string { return s.path }
+
+func (s *DB) migrate() error {
+	var version int
+	if err := s.db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	if version > len(schema) {
+		return fmt.Errorf("%w: database has schema %d, this build reads up to %d", ErrNewerSchema, version, len(schema))
+	}
+	for ; version < len(schema); version++ {
+		err := s.tx(func(tx *sql.Tx) error {
+			if _, err := tx.Exec(schema[version]); err != nil {
+				return err
+			}
+			_, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, version+1))
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migrate database to schema %d: %w", version+1, err)
+		}
+	}
+	return nil
+}
+
+func (s *DB) tx(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Get decodes the document stored under key into v.
+func (s *DB) Get(key string, v any) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	return getDocument(s.db, key, v)
+}
+
+// Put stores v under key, replacing any previous value.
+func (s *DB) Put(key string, v any) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	return putDocument(s.db, key, v)
+}
+
+// Update reads key into v, calls fn to change it and stores the result in one
+// transaction. A missing key leaves v as it is; an error from fn cancels the
+// write.
+func (s *DB) Update(key string, v any, fn func() error) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	return s.tx(func(tx *sql.Tx) error {
+		if err := getDocument(tx, key, v); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		if err := fn(); err != nil {
+			return err
+		}
+		return putDocument(tx, key, v)
+	})
+}
+
+// Delete removes key. Deleting a key that was never stored is not an error.
+func (s *DB) Delete(key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	if _, err := s.db.Exec(`DELETE FROM documents WHERE key = ?`, key); err != nil {
+		return fmt.Errorf("delete %q: %w", key, err)
+	}
+	return nil
+}
+
+// Keys lists every stored document key, sorted.
+func (s *DB) Keys() ([]string, error) {
+	rows, err := s.db.Query(`SELECT key FROM documents ORDER BY key`)
+	if err != nil {
+		return nil, fmt.Errorf("list store: %w", err)
+	}
+	defer rows.Close()
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("list store: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// querier is what *sql.DB and *sql.Tx have in common.
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func getDocument(q querier, key string, v any) error {
+	var doc document
+	var data []byte
+	err := q.QueryRow(`SELECT schema, data FROM documents WHERE key = ?`, key).Scan(&doc.Schema, &data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("read %q: %w", key, err)
+	}
+	doc.Data = data
+	if doc.Schema != SchemaVersion {
+		if doc, err = upgrade(key, doc); err != nil {
+			return err
+		}
+	}
+	if err := json.Unmarshal(doc.Data, v); err != nil {
+		return fmt.Errorf("decode %q: %w", key, err)
+	}
+	return nil
+}
+
+func putDocument(q querier, key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encode %q: %w", key, err)
+	}
+	return putRaw(q, key, document{Schema: SchemaVersion, Data: data})
+}
+
+func putRaw(q querier, key string, doc document) error {
+	_, err := q.Exec(
+		`INSERT INTO documents (key, schema, data, updated_at) VALUES (?, ?, ?, ?)
+		 ON CONFLICT (key) DO UPDATE SET schema = excluded.schema, data = excluded.data, updated_at = excluded.updated_at`,
+		key, doc.Schema, []byte(doc.Data), time.Now().UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("write %q: %w", key, err)
+	}
+	return nil
+}
+
+// RecordSearch stores a search with the offers it returned, and records the
+// cheapest of them in each currency as a price observation. The returned
+// Search has its ID and OfferCount set.
+func (s *DB) RecordSearch(search Search, offers []types.FlightOffer) (Search, error) {
+	err := s.tx(func(tx *sql.Tx) error {
+		return insertSearch(tx, &search, offers)
+	})
+	if err != nil {
+		return search, fmt.Errorf("record search: %w", err)
+	}
+	return search, nil
+}
+
+func insertSearch(tx *sql.Tx, search *Search, offers []types.FlightOffer) error {
+	search.OfferCount = len(offers)
+	res, err := tx.Exec(
+		`INSERT INTO searches (origin, destination, departure_date, provider, searched_at) VALUES (?, ?, ?, ?, ?)`,
+		search.Origin, search.Destination, search.DepartureDate, search.Provider, search.SearchedAt.UnixMilli(),
+	)
+	if err != nil {
+		return err
+	}
+	if search.ID, err = res.LastInsertId(); err != nil {
+		return err
+	}
+
+	for i, offer := range offers {
+		if err := insertOffer(tx, search.ID, i, offer); err != nil {
+			return err
+		}
+	}
+
+	for _, cheapest := range cheapestByCurrency(offers) {
+		_, err := tx.Exec(
+			`INSERT INTO price_observations (search_id, origin, destination, departure_date, provider, observed_at, amount, currency)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			search.ID, search.Origin, search.Destination, search.DepartureDate, search.Provider,
+			search.SearchedAt.UnixMilli(), cheapest.Amount, cheapest.Currency,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertOffer(tx *sql.Tx, searchID int64, position int, offer types.FlightOffer) error {
+	data, err := json.Marshal(offer)
+	if err != nil {
+		return err
+	}
+	res, err := tx.Exec(
+		`INSERT INTO offers (search_id, position, provider, offer_id, price_amount, price_currency, data) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		searchID, position, offer.Provider, offer.OfferID, offer.TotalPrice.Amount, offer.TotalPrice.Currency, data,
+	)
+	if err != nil {
+		return err
+	}
+	rowID, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	for i, seg := range offer.Segments {
+		_, err := tx.Exec(
+			`INSERT INTO segments (offer_id, position, origin, destination, depart_at, arrive_at, carrier, flight_no, cabin)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			rowID, i, seg.From, seg.To, seg.DepartAt.Format(time.RFC3339), seg.ArriveAt.Format(time.RFC3339),
+			seg.Carrier, seg.FlightNo, seg.Cabin,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cheapestByCurrency picks the lowest quoted price in each currency, in
+// the order the currencies first appear. It goes by TotalPrice, like the
+// offers table, so an observation always matches a stored offer; amounts in
+// different currencies are never compared.
+func cheapestByCurrency(offers []types.FlightOffer) []types.Money {
+	var cheapest []types.Money
+	for _, o := range offers {
+		price := o.TotalPrice
+		i := slices.IndexFunc(cheapest, func(m types.Money) bool { return m.Currency == price.Currency })
+		switch {
+		case i < 0:
+			cheapest = append(cheapest, price)
+		case price.Amount < cheapest[i].Amount:
+			cheapest[i] = price
+		}
+	}
+	return cheapest
+}
+
+// Searches lists the search history, newest first.
+func (s *DB) Searches() ([]Search, error) {
+	rows, err := s.db.Query(
+		`SELECT s.id, s.origin, s.destination, s.departure_date, s.provider, s.searched_at,
+		        (SELECT COUNT(*) FROM offers o WHERE o.search_id = s.id)
+		 FROM searches s ORDER BY s.searched_at DESC, s.id DESC`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list searches: %w", err)
+	}
+	defer rows.Close()
+
+	var searches []Search
+	for rows.Next() {
+		var search Search
+		var searchedAt int64
+		err := rows.Scan(&search.ID, &search.Origin, &search.Destination, &search.DepartureDate,
+			&search.Provider, &searchedAt, &search.OfferCount)
+		if err != nil {
+			return nil, fmt.Errorf("list searches: %w", err)
+		}
+		search.SearchedAt = time.UnixMilli(searchedAt)
+		searches = append(searches, search)
+	}
+	return searches, rows.Err()
+}
+
+// SearchOffers returns the offers a search returned, in their original order.
+func (s *DB) SearchOffers(searchID int64) ([]types.FlightOffer, error) {
+	rows, err := s.db.Query(`SELECT data FROM offers WHERE search_id = ? ORDER BY position`, searchID)
+	if err != nil {
+		return nil, fmt.Errorf("load offers: %w", err)
+	}
+	defer rows.Close()
+
+	var offers []types.FlightOffer
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("load offers: %w", err)
+		}
+		var offer types.FlightOffer
+		if err := json.Unmarshal(data, &offer); err != nil {
+			return nil, fmt.Errorf("decode offer: %w", err)
+		}
+		offers = append(offers, offer)
+	}
+	return offers, rows.Err()
+}
+
+// DeleteSearch removes a search and its offers. Its price observation stays.
+func (s *DB) DeleteSearch(id int64) error {
+	if _, err := s.db.Exec(`DELETE FROM searches WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("delete search: %w", err)
+	}
+	return nil
+}
+
+// PruneSearches keeps only the newest keep searches.
+func (s *DB) PruneSearches(keep int) error {
+	_, err := s.db.Exec(
+		`DELETE FROM searches WHERE id NOT IN (SELECT id FROM searches ORDER BY searched_at DESC, id DESC LIMIT ?)`,
+		keep,
+	)
+	if err != nil {
+		return fmt.Errorf("prune searches: %w", err)
+	}
+	return nil
+}
+
+// CheapestSince returns the lowest price in currency seen for a route since
+// the given time, e.g. the cheapest YYZ→CPH in CAD in the last 30 days.
+func (s *DB) CheapestSince(origin, destination, currency string, since time.Time) (PriceObservation, bool, error) {
+	var obs PriceObservation
+	var observedAt int64
+	err := s.db.QueryRow(
+		`SELECT origin, destination, departure_date, provider, observed_at, amount, currency
+		 FROM price_observations
+		 WHERE origin = ? AND destination = ? AND currency = ? AND observed_at >= ?
+		 ORDER BY amount ASC, observed_at DESC LIMIT 1`,
+		origin, destination, currency, since.UnixMilli(),
+	).Scan(&obs.Origin, &obs.Destination, &obs.DepartureDate, &obs.Provider, &observedAt,
+		&obs.Price.Amount, &obs.Price.Currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return obs, false, nil
+	}
+	if err != nil {
+		return obs, false, fmt.Errorf("cheapest price: %w", err)
+	}
+	obs.ObservedAt = time.UnixMilli(observedAt)
+	return obs, true, nil
+}
+
+// PastSearch is a search from the JSON store's history, with its offers.
+type PastSearch struct {
+	Search Search
+	Offers []types.FlightOffer
+}
+
+// Import records the history of a JSON store and copies its documents into
+// the database, keeping their schema versions, except the keys skip returns
+// true for. Existing documents with the same key are replaced. It all
+// happens in one transaction, so an import that fails leaves nothing behind
+// and can simply be run again. Unreadable documents are logged and not
+// imported; their only copy is the file in the JSON store.
+func (s *DB) Import(from *Store, history []PastSearch, skip func(key string) bool) error {
+	return from.withLock(false, func() error {
+		keys, err := from.keys()
+		if err != nil {
+			return err
+		}
+		return s.tx(func(tx *sql.Tx) error {
+			for _, past := range history {
+				if err := insertSearch(tx, &past.Search, past.Offers); err != nil {
+					return fmt.Errorf("record search: %w", err)
+				}
+			}
+			for _, key := range keys {
+				if skip != nil && skip(key) {
+					continue
+				}
+				doc, err := from.read(key)
+				if err != nil {
+					slog.Warn("skip unreadable document", "key", key, "err", err)
+					continue
+				}
+				if err := putRaw(tx, key, doc); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
@@ -0,0 +1,86 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/justinm35/flyctl/types"
+)
+
+func TestOpenDBMigrates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flyctl.db")
+	for range 2 { // the second open finds the schema up to date
+		db, err := OpenDB(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var version int
+		if err := db.db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+			t.Fatal(err)
+		}
+		if version != len(schema) {
+			t.Errorf("user_version = %d, want %d", version, len(schema))
+		}
+		db.Close()
+	}
+}
+
+func TestOpenDBNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flyctl.db")
+	raw, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := raw.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, len(schema)+1)); err != nil {
+		t.Fatal(err)
+	}
+	raw.Close()
+
+	if db, err := OpenDB(path); !errors.Is(err, ErrNewerSchema) {
+		if err == nil {
+			db.Close()
+		}
+		t.Errorf("OpenDB = %v, want ErrNewerSchema", err)
+	}
+}
+
+func TestCheapestByCurrency(t *testing.T) {
+	offer := func(amount int64, currency string, converted *types.Money) types.FlightOffer {
+		o := types.FlightOffer{TotalPrice: types.Money{Amount: amount, Currency: currency}}
+		if converted != nil {
+			o.Converted = &types.Conversion{Price: *converted}
+		}
+		return o
+	}
+	tests := []struct {
+		name   string
+		offers []types.FlightOffer
+		want   []types.Money
+	}{
+		{"none", nil, nil},
+		{
+			"one currency",
+			[]types.FlightOffer{offer(90000, "CAD", nil), offer(61200, "CAD", nil), offer(70000, "CAD", nil)},
+			[]types.Money{{Amount: 61200, Currency: "CAD"}},
+		},
+		{
+			"currencies kept apart, in order of appearance",
+			[]types.FlightOffer{offer(50000, "EUR", nil), offer(61200, "CAD", nil), offer(45000, "EUR", nil)},
+			[]types.Money{{Amount: 45000, Currency: "EUR"}, {Amount: 61200, Currency: "CAD"}},
+		},
+		{
+			"quoted price, not the converted one",
+			[]types.FlightOffer{offer(45000, "EUR", &types.Money{Amount: 66000, Currency: "CAD"})},
+			[]types.Money{{Amount: 45000, Currency: "EUR"}},
+		},
+	}
+	for _, tt := range tests {
+		if got := cheapestByCurrency(tt.offers); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: cheapestByCurrency = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+// Package store keeps flyctl's local data: one JSON document per key in a
+// single directory. Documents carry a schema version and are upgraded on
+// open, every write replaces the file atomically, and a lock file keeps
+// several flyctl processes from interleaving their changes.
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	fileMode = 0o600
+	dirMode  = 0o700
+	lockName = ".lock"
+	ext      = ".json"
+)
+
+var (
+	// ErrNotFound is returned by Get for a key that was never stored.
+	ErrNotFound = errors.New("store: key not found")
+	// ErrInvalidKey is returned for keys that are not a plain file name.
+	ErrInvalidKey = errors.New("store: invalid key")
+	// ErrNewerSchema is returned for documents written by a newer flyctl.
+	ErrNewerSchema = errors.New("store: document was written by a newer version of flyctl")
+)
+
+var validKey = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Store is a directory of versioned JSON documents.
+type Store struct {
+	dir string
+}
+
+// document is the on-disk envelope around every stored value.
+type document struct {
+	Schema int             `json:"schema"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Open opens the store in dir, creating it if needed, and upgrades any
+// documents written with an older schema.
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return nil, fmt.Errorf("create store dir %q: %w", dir, err)
+	}
+	s := &Store{dir: dir}
+	if err := s.migrate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Dir is the directory the store lives in.
+func (s *Store) Dir() string { return s.dir }
+
+// Get decodes the value stored under key into v.
+func (s *Store) Get(key string, v any) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	return s.withLock(false, func() error { return s.get(key, v) })
+}
+
+// Put stores v under key, replacing any previous value.
+func (s *Store) Put(key string, v any) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	return s.withLock(true, func() error { return s.put(key, v) })
+}
+
+// Update reads key into v, calls fn to change it and stores the result, all
+// under one lock so concurrent updates from other processes are not lost. A
+// missing key leaves v as it is; an error from fn cancels the write.
+func (s *Store) Update(key string, v any, fn func() error) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	return s.withLock(true, func() error {
+		if err := s.get(key, v); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		if err := fn(); err != nil {
+			return err
+		}
+		return s.put(key, v)
+	})
+}
+
+// Delete removes key. Deleting a key that was never stored is not an error.
+func (s *Store) Delete(key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	return s.withLock(true, func() error {
+		err := os.Remove(s.path(key))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("delete %q: %w", key, err)
+		}
+		return nil
+	})
+}
+
+// Keys lists every stored key, sorted.
+func (s *Store) Keys() ([]string, error) {
+	var keys []string
+	err := s.withLock(false, func() error {
+		var err error
+		keys, err = s.keys()
+		return err
+	})
+	return keys, err
+}
+
+func (s *Store) keys() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("list store: %w", err)
+	}
+	var keys []string
+	for _, e := range entries {
+		key, ok := strings.CutSuffix(e.Name(), ext)
+		if !ok || e.IsDir() || checkKey(key) != nil {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (s *Store) path(key string) string { return filepath.Join(s.dir, key+ext) }
+
+func (s *Store) get(key string, v any) error {
+	doc, err := s.read(key)
+	if err != nil {
+		return err
+	}
+	if doc.Schema != SchemaVersion {
+		if doc, err = upgrade(key, doc); err != nil {
+			return err
+		}
+	}
+	if err := json.Unmarshal(doc.Data, v); err != nil {
+		return fmt.Errorf("decode %q: %w", key, err)
+	}
+	return nil
+}
+
+func (s *Store) put(key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encode %q: %w", key, err)
+	}
+	return s.write(key, document{Schema: SchemaVersion, Data: data})
+}
+
+// read loads a document. Files without an envelope predate schema versions
+// and are read as schema 0.
+func (s *Store) read(key string) (document, error) {
+	raw, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return document{}, ErrNotFound
+	}
+	if err != nil {
+		return document{}, fmt.Errorf("read %q: %w", key, err)
+	}
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return document{}, fmt.Errorf("read %q: file is empty", key)
+	}
+
+	var doc document
+	if raw[0] == '{' && json.Unmarshal(raw, &doc) == nil && doc.Schema > 0 && doc.Data != nil {
+		return doc, nil
+	}
+	if !json.Valid(raw) {
+		return document{}, fmt.Errorf("read %q: not valid JSON", key)
+	}
+	return document{Schema: 0, Data: raw}, nil
+}
+
+// write replaces a document atomically, see WriteFile.
+func (s *Store) write(key string, doc document) error {
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("encode %q: %w", key, err)
+	}
+	if err := WriteFile(s.path(key), encoded, fileMode); err != nil {
+		return fmt.Errorf("write %q: %w", key, err)
+	}
+	return nil
+}
+
+// withLock runs fn holding the store lock, shared for reads and exclusive
+// for writes.
+func (s *Store) withLock(exclusive bool, fn func() error) error {
+	f, err := os.OpenFile(filepath.Join(s.dir, lockName), os.O_CREATE|os.O_RDWR, fileMode)
+	if err != nil {
+		return fmt.Errorf("open store lock: %w", err)
+	}
+	defer f.Close()
+
+	if err := lockFile(f, exclusive); err != nil {
+		return fmt.Errorf("lock store: %w", err)
+	}
+	defer unlockFile(f)
+
+	return fn()
+}
+
+func checkKey(key string) error {
+	if !validKey.MatchString(key) || strings.HasSuffix(key, ".tmp") {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return nil
+}
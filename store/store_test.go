@@ -0,0 +1,268 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{"object", map[string]int{"adults": 2}, `{"schema":1,"data":{"adults":2}}`},
+		{"list", []string{"YYZ", "CPH"}, `{"schema":1,"data":["YYZ","CPH"]}`},
+		{"string", "hello", `{"schema":1,"data":"hello"}`},
+	}
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		if err := s.Put("doc", "old"); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Put("doc", tt.value); err != nil {
+			t.Fatalf("%s: Put: %v", tt.name, err)
+		}
+		raw, err := os.ReadFile(s.path("doc"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(raw) != tt.want {
+			t.Errorf("%s: file holds %s, want %s", tt.name, raw, tt.want)
+		}
+		info, err := os.Stat(s.path("doc"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode := info.Mode().Perm(); mode != fileMode && runtime.GOOS != "windows" {
+			t.Errorf("%s: file mode %o, want %o", tt.name, mode, fileMode)
+		}
+	}
+
+	entries, err := os.ReadDir(s.Dir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".tmp") {
+			t.Errorf("temporary file %s left behind", e.Name())
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   string
+		schema int
+		data   string
+		fails  bool
+	}{
+		{"envelope", `{"schema":1,"data":{"a":1}}`, 1, `{"a":1}`, false},
+		{"bare object from before versions", `{"a":1}`, 0, `{"a":1}`, false},
+		{"bare list from before versions", " [1, 2]\n", 0, `[1, 2]`, false},
+		{"object with a schema field of its own", `{"schema":0,"data":1}`, 0, `{"schema":0,"data":1}`, false},
+		{"empty", "  \n", 0, "", true},
+		{"half written", `{"schema":1,"data":`, 0, "", true},
+	}
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		if err := os.WriteFile(s.path("doc"), []byte(tt.file), fileMode); err != nil {
+			t.Fatal(err)
+		}
+		doc, err := s.read("doc")
+		if tt.fails {
+			if err == nil {
+				t.Errorf("%s: read succeeded", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: read: %v", tt.name, err)
+			continue
+		}
+		if doc.Schema != tt.schema || string(doc.Data) != tt.data {
+			t.Errorf("%s: read schema %d data %s, want schema %d data %s", tt.name, doc.Schema, doc.Data, tt.schema, tt.data)
+		}
+	}
+	if _, err := s.read("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("read of a missing key = %v, want ErrNotFound", err)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	for _, key := range []string{"", ".hidden", "a/b", "../up", "doc.tmp", "with space"} {
+		if err := checkKey(key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("checkKey(%q) = %v, want ErrInvalidKey", key, err)
+		}
+	}
+	for _, key := range []string{"settings", "savedSearches", "searchSnapshot-1700000000", "a.b_c"} {
+		if err := checkKey(key); err != nil {
+			t.Errorf("checkKey(%q) = %v", key, err)
+		}
+	}
+}
+
+// withMigrations swaps in a migration chain for the length of a test.
+func withMigrations(t *testing.T, chain []migration) {
+	oldMigrations, oldVersion := migrations, SchemaVersion
+	migrations, SchemaVersion = chain, len(chain)
+	t.Cleanup(func() { migrations, SchemaVersion = oldMigrations, oldVersion })
+}
+
+func TestUpgrade(t *testing.T) {
+	// each step appends its number, so the result shows which ran in what order
+	step := func(n string) migration {
+		return func(_ string, data json.RawMessage) (json.RawMessage, error) {
+			var s string
+			if err := json.Unmarshal(data, &s); err != nil {
+				return nil, err
+			}
+			return json.Marshal(s + n)
+		}
+	}
+	withMigrations(t, []migration{step("1"), step("2"), step("3")})
+
+	tests := []struct {
+		schema int
+		data   string
+		want   string
+	}{
+		{0, `"v"`, `"v123"`},
+		{1, `"v"`, `"v23"`},
+		{2, `"v"`, `"v3"`},
+		{3, `"v"`, `"v"`},
+	}
+	for _, tt := range tests {
+		doc, err := upgrade("doc", document{Schema: tt.schema, Data: json.RawMessage(tt.data)})
+		if err != nil {
+			t.Errorf("upgrade from %d: %v", tt.schema, err)
+			continue
+		}
+		if doc.Schema != 3 || string(doc.Data) != tt.want {
+			t.Errorf("upgrade from %d = schema %d data %s, want schema 3 data %s", tt.schema, doc.Schema, doc.Data, tt.want)
+		}
+	}
+
+	if _, err := upgrade("doc", document{Schema: 0, Data: json.RawMessage(`42`)}); err == nil {
+		t.Error("upgrade with a failing step succeeded")
+	}
+}
+
+func TestOpenMigrates(t *testing.T) {
+	withMigrations(t, []migration{
+		func(_ string, data json.RawMessage) (json.RawMessage, error) { return data, nil },
+		func(_ string, data json.RawMessage) (json.RawMessage, error) {
+			return json.RawMessage(`{"wrapped":` + string(data) + `}`), nil
+		},
+	})
+	dir := t.TempDir()
+	files := map[string]string{
+		"bare":    `[1,2]`,
+		"current": `{"schema":2,"data":"kept"}`,
+		"broken":  `{"schema":`,
+	}
+	for key, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, key+ext), []byte(content), fileMode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open with a broken document: %v", err)
+	}
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"bare", `{"schema":2,"data":{"wrapped":[1,2]}}`},
+		{"current", `{"schema":2,"data":"kept"}`},
+		{"broken", `{"schema":`}, // left as it was
+	}
+	for _, tt := range tests {
+		raw, err := os.ReadFile(s.path(tt.key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(raw) != tt.want {
+			t.Errorf("%s after Open = %s, want %s", tt.key, raw, tt.want)
+		}
+	}
+}
+
+func TestNewerSchema(t *testing.T) {
+	dir := t.TempDir()
+	newer := `{"schema":999,"data":{"from":"the future"}}`
+	if err := os.WriteFile(filepath.Join(dir, "doc"+ext), []byte(newer), fileMode); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	var v any
+	if err := s.Get("doc", &v); !errors.Is(err, ErrNewerSchema) {
+		t.Errorf("Get = %v, want ErrNewerSchema", err)
+	}
+	err = s.Update("doc", &v, func() error { return nil })
+	if !errors.Is(err, ErrNewerSchema) {
+		t.Errorf("Update = %v, want ErrNewerSchema", err)
+	}
+	raw, err := os.ReadFile(s.path("doc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != newer {
+		t.Errorf("document rewritten to %s", raw)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		perm os.FileMode
+	}{
+		{"new file", "config.yaml", 0o600},
+		{"replaced file", "config.yaml", 0o644},
+		{"missing directories", filepath.Join("flyctl", "cache", "rates.json"), 0o600},
+	}
+	dir := t.TempDir()
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.path)
+		if err := WriteFile(path, []byte(tt.name), tt.perm); err != nil {
+			t.Fatalf("%s: WriteFile: %v", tt.name, err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.name {
+			t.Errorf("%s: file holds %q", tt.name, got)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode := info.Mode().Perm(); mode != tt.perm && runtime.GOOS != "windows" {
+			t.Errorf("%s: file mode %o, want %o", tt.name, mode, tt.perm)
+		}
+		matches, _ := filepath.Glob(path + ".*.tmp")
+		if len(matches) > 0 {
+			t.Errorf("%s: temporary files left behind: %v", tt.name, matches)
+		}
+	}
+}
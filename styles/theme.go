@@ -0,0 +1,190 @@
+package styles
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme is a named set of colors for every role the UI draws with. Screens
+// should never reach for a raw color; they ask the active theme instead.
+type Theme struct {
+	Name string
+
+	Text       lipgloss.TerminalColor // regular text
+	Muted      lipgloss.TerminalColor // hints, bottom bar, empty states
+	Accent     lipgloss.TerminalColor // pane titles and the focused border
+	Label      lipgloss.TerminalColor // form labels
+	Border     lipgloss.TerminalColor // unfocused pane borders
+	Rule       lipgloss.TerminalColor // separators such as the table header rule
+	SelectedFg lipgloss.TerminalColor // selected table row
+	SelectedBg lipgloss.TerminalColor
+	Spinner    lipgloss.TerminalColor
+	Good       lipgloss.TerminalColor // positive highlights (best value, low emissions)
+	Warning    lipgloss.TerminalColor // things worth a second look
+	Danger     lipgloss.TerminalColor // errors and risky options
+	Info       lipgloss.TerminalColor
+
+	// NoColor marks themes that must stay readable without any color, so
+	// selection falls back to reverse video.
+	NoColor bool
+}
+
+var Dark = Theme{
+	Name:       "dark",
+	Text:       White,
+	Muted:      MutedGray,
+	Accent:     NeonPurple,
+	Label:      HotPink,
+	Border:     White,
+	Rule:       lipgloss.Color("240"),
+	SelectedFg: lipgloss.Color("229"),
+	SelectedBg: lipgloss.Color("57"),
+	Spinner:    lipgloss.Color("205"),
+	Good:       NeonGreen,
+	Warning:    NeonOrange,
+	Danger:     HotPink,
+	Info:       ElectricBlue,
+}
+
+var Light = Theme{
+	Name:       "light",
+	Text:       lipgloss.Color("#1F2933"),
+	Muted:      lipgloss.Color("#52606D"),
+	Accent:     lipgloss.Color("#5B21B6"),
+	Label:      lipgloss.Color("#BE185D"),
+	Border:     lipgloss.Color("#9AA5B1"),
+	Rule:       lipgloss.Color("#9AA5B1"),
+	SelectedFg: lipgloss.Color("#FFFFFF"),
+	SelectedBg: lipgloss.Color("#5B21B6"),
+	Spinner:    lipgloss.Color("#BE185D"),
+	Good:       lipgloss.Color("#047857"),
+	Warning:    lipgloss.Color("#B45309"),
+	Danger:     lipgloss.Color("#B91C1C"),
+	Info:       lipgloss.Color("#1D4ED8"),
+}
+
+var HighContrast = Theme{
+	Name:       "high-contrast",
+	Text:       lipgloss.Color("15"),
+	Muted:      lipgloss.Color("7"),
+	Accent:     lipgloss.Color("11"),
+	Label:      lipgloss.Color("14"),
+	Border:     lipgloss.Color("15"),
+	Rule:       lipgloss.Color("15"),
+	SelectedFg: lipgloss.Color("0"),
+	SelectedBg: lipgloss.Color("11"),
+	Spinner:    lipgloss.Color("11"),
+	Good:       lipgloss.Color("10"),
+	Warning:    lipgloss.Color("11"),
+	Danger:     lipgloss.Color("9"),
+	Info:       lipgloss.Color("14"),
+}
+
+var Plain = Theme{
+	Name:       "no-color",
+	Text:       lipgloss.NoColor{},
+	Muted:      lipgloss.NoColor{},
+	Accent:     lipgloss.NoColor{},
+	Label:      lipgloss.NoColor{},
+	Border:     lipgloss.NoColor{},
+	Rule:       lipgloss.NoColor{},
+	SelectedFg: lipgloss.NoColor{},
+	SelectedBg: lipgloss.NoColor{},
+	Spinner:    lipgloss.NoColor{},
+	Good:       lipgloss.NoColor{},
+	Warning:    lipgloss.NoColor{},
+	Danger:     lipgloss.NoColor{},
+	Info:       lipgloss.NoColor{},
+	NoColor:    true,
+}
+
+var builtins = map[string]Theme{
+	Dark.Name:         Dark,
+	Light.Name:        Light,
+	HighContrast.Name: HighContrast,
+	Plain.Name:        Plain,
+}
+
+var active = Dark
+
+// Active returns the theme screens should draw with.
+func Active() Theme { return active }
+
+// SetActive switches the theme used by every screen.
+func SetActive(t Theme) { active = t }
+
+// Builtin looks up one of the themes shipped with flyctl.
+func Builtin(name string) (Theme, bool) {
+	t, ok := builtins[strings.ToLower(name)]
+	return t, ok
+}
+
+// BuiltinNames lists the shipped themes in a stable order.
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithColors returns a copy of base with the given roles overridden. Role
+// names are the lower-case field names, e.g. "accent" or "selected_bg".
+// Values are anything lipgloss.Color accepts: hex codes or ANSI numbers.
+func (t Theme) WithColors(name string, colors map[string]string) (Theme, error) {
+	out := t
+	out.Name = name
+	for role, value := range colors {
+		c := lipgloss.Color(strings.TrimSpace(value))
+		switch strings.ToLower(role) {
+		case "base":
+			// handled by the caller
+		case "text":
+			out.Text = c
+		case "muted":
+			out.Muted = c
+		case "accent":
+			out.Accent = c
+		case "label":
+			out.Label = c
+		case "border":
+			out.Border = c
+		case "rule":
+			out.Rule = c
+		case "selected_fg":
+			out.SelectedFg = c
+		case "selected_bg":
+			out.SelectedBg = c
+		case "spinner":
+			out.Spinner = c
+		case "good":
+			out.Good = c
+		case "warning":
+			out.Warning = c
+		case "danger":
+			out.Danger = c
+		case "info":
+			out.Info = c
+		default:
+			return Theme{}, fmt.Errorf("theme %q: unknown color role %q", name, role)
+		}
+	}
+	return out, nil
+}
+
+// Title is the style for pane headings like "[Results]".
+func (t Theme) Title() lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(t.Accent).Bold(true)
+}
+
+// Selected is the style for the highlighted row of a table or list.
+func (t Theme) Selected() lipgloss.Style {
+	if t.NoColor {
+		return lipgloss.NewStyle().Reverse(true).Bold(true)
+	}
+	return lipgloss.NewStyle().Foreground(t.SelectedFg).Background(t.SelectedBg).Bold(true)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/justinm35/flyctl/styles"
+)
+
+const tabBarHeight = 1
+
+// searchTab is one open search. The active tab lives in Model.screenSearch,
+// Model.screenResults and Model.screenFlightDetails; its entry in Model.tabs
+// is only brought up to date when another tab is selected.
+type searchTab struct {
+	id            int
+	search        SearchState
+	results       ResultsState
+	flightDetails FlightDetailsState
+}
+
+func (m *Model) newTab() tea.Cmd {
+	m.nextTabID++
+	tab := searchTab{
+		id:            m.nextTabID,
+		search:        newSearchState(),
+		results:       newResultsState(m.keys.Results),
+		flightDetails: newFlightDetailsState(m.keys.Details),
+	}
+	if len(m.tabs) > 0 {
+		m.saveTab()
+	}
+	m.tabs = append(m.tabs, tab)
+	cmd := m.loadTab(len(m.tabs) - 1)
+	m.focusPane(screenSearch)
+	return tea.Batch(cmd, m.screenSearch.initCmd())
+}
+
+// closeTab closes the active tab. The last tab is never closed.
+func (m *Model) closeTab() tea.Cmd {
+	if len(m.tabs) == 1 {
+		return m.setStatus("can't close the last tab")
+	}
+	m.tabs = append(m.tabs[:m.activeTab], m.tabs[m.activeTab+1:]...)
+	return m.loadTab(min(m.activeTab, len(m.tabs)-1))
+}
+
+// switchTab makes tab i active, wrapping around at either end.
+func (m *Model) switchTab(i int) tea.Cmd {
+	i = (i + len(m.tabs)) % len(m.tabs)
+	if i == m.activeTab {
+		return nil
+	}
+	m.saveTab()
+	return m.loadTab(i)
+}
+
+func (m *Model) saveTab() {
+	tab := &m.tabs[m.activeTab]
+	tab.search = m.screenSearch
+	tab.results = m.screenResults
+	tab.flightDetails = m.screenFlightDetails
+}
+
+func (m *Model) loadTab(i int) tea.Cmd {
+	m.activeTab = i
+	tab := m.tabs[i]
+	m.screenSearch = tab.search
+	m.screenResults = tab.results
+	m.screenFlightDetails = tab.flightDetails
+	m.applyLayout()
+	m.applyTheme()
+
+	// spinner ticks for background tabs are dropped, so restart it
+	if m.screenSearch.loading {
+		return m.screenSearch.spinner.Tick
+	}
+	return nil
+}
+
+func (m Model) activeTabID() int { return m.tabs[m.activeTab].id }
+
+// tabIndex finds a tab by id; tabs can close while their search is running.
+func (m Model) tabIndex(id int) (int, bool) {
+	for i, tab := range m.tabs {
+		if tab.id == id {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// tabTitle describes a tab by its search, e.g. "YYZ→CPH Nov 2".
+func tabTitle(s SearchState) string {
+	from := strings.ToUpper(strings.TrimSpace(s.inputs[0].Value()))
+	to := strings.ToUpper(strings.TrimSpace(s.inputs[1].Value()))
+	if from == "" && to == "" {
+		return "new search"
+	}
+	title := fmt.Sprintf("%s→%s", emptyDash(from), emptyDash(to))
+	if d, err := time.Parse("2006-01-02", strings.TrimSpace(s.inputs[2].Value())); err == nil {
+		title += " " + d.Format("Jan 2")
+	}
+	return title
+}
+
+// tabLabels returns the rendered width-defining text of every tab.
+func (m Model) tabLabels() []string {
+	labels := make([]string, len(m.tabs))
+	for i, tab := range m.tabs {
+		search := tab.search
+		if i == m.activeTab {
+			search = m.screenSearch
+		}
+		label := fmt.Sprintf(" %d %s ", i+1, tabTitle(search))
+		if search.loading {
+			label = fmt.Sprintf(" %d %s … ", i+1, tabTitle(search))
+		}
+		labels[i] = label
+	}
+	return labels
+}
+
+func (m Model) viewTabs() string {
+	theme := styles.Active()
+	var parts []string
+	for i, label := range m.tabLabels() {
+		if i == m.activeTab {
+			parts = append(parts, theme.Selected().Render(label))
+		} else {
+			parts = append(parts, lipgloss.NewStyle().Foreground(theme.Muted).Render(label))
+		}
+	}
+	return lipgloss.NewStyle().
+		Width(m.width).
+		MaxWidth(m.width).
+		Render(strings.Join(parts, " "))
+}
+
+// tabAt maps an x position on the tab strip to a tab index.
+func (m Model) tabAt(x int) (int, bool) {
+	edge := 0
+	for i, label := range m.tabLabels() {
+		edge += lipgloss.Width(label)
+		if x < edge {
+			return i, true
+		}
+		edge++ // separator
+	}
+	return 0, false
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/justinm35/flyctl/styles"
+	"github.com/muesli/termenv"
+	"github.com/spf13/viper"
+)
+
+// loadTheme resolves the configured theme. NO_COLOR always wins; otherwise
+// "theme" names either a built-in theme or an entry under "themes":
+//
+//	theme: solarized
+//	themes:
+//	  solarized:
+//	    base: light
+//	    accent: "#268bd2"
+//	    label: "#d33682"
+func loadTheme() (styles.Theme, error) {
+	if os.Getenv("NO_COLOR") != "" {
+		lipgloss.SetColorProfile(termenv.Ascii)
+		return styles.Plain, nil
+	}
+	return resolveTheme(viper.GetString("theme"))
+}
+
+func resolveTheme(name string) (styles.Theme, error) {
+	if name == "" {
+		return styles.Dark, nil
+	}
+	if t, ok := styles.Builtin(name); ok {
+		return t, nil
+	}
+
+	colors := viper.GetStringMapString("themes." + name)
+	if len(colors) == 0 {
+		return styles.Dark, fmt.Errorf("unknown theme %q", name)
+	}
+	base := styles.Dark
+	if baseName, ok := colors["base"]; ok {
+		b, ok := styles.Builtin(baseName)
+		if !ok {
+			return styles.Dark, fmt.Errorf("theme %q: unknown base theme %q", name, baseName)
+		}
+		base = b
+	}
+	return base.WithColors(name, colors)
+}
+
+// themeNames lists every theme that can be selected, built-in first.
+func themeNames() []string {
+	names := styles.BuiltinNames()
+	for name := range viper.GetStringMap("themes") {
+		if _, ok := styles.Builtin(name); !ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// applyTheme restyles the components that cache their styles.
+func (m *Model) applyTheme() {
+	theme := styles.Active()
+
+	m.screenResults.table.SetStyles(tableStyles(theme))
+	m.screenSearch.spinner.Style = lipgloss.NewStyle().Foreground(theme.Spinner)
+	for i := range m.screenSearch.inputs {
+		m.screenSearch.inputs[i].PlaceholderStyle = lipgloss.NewStyle().Foreground(theme.Muted)
+		m.screenSearch.inputs[i].TextStyle = m.screenSearch.inputs[i].TextStyle.Foreground(theme.Text)
+		m.screenSearch.inputs[i].Cursor.Style = lipgloss.NewStyle().Foreground(theme.Accent)
+	}
+	m.help.Styles = helpStyles(theme)
+	m.screenFlightDetails.refresh()
+}
+
+func tableStyles(theme styles.Theme) table.Styles {
+	s := table.DefaultStyles()
+	s.Header = s.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(theme.Rule).
+		BorderBottom(true).
+		Foreground(theme.Text).
+		Bold(true)
+	s.Selected = theme.Selected()
+	return s
+}
+
+func helpStyles(theme styles.Theme) help.Styles {
+	keyStyle := lipgloss.NewStyle().Foreground(theme.Text)
+	descStyle := lipgloss.NewStyle().Foreground(theme.Muted)
+	sepStyle := lipgloss.NewStyle().Foreground(theme.Rule)
+	return help.Styles{
+		ShortKey:       keyStyle,
+		ShortDesc:      descStyle,
+		ShortSeparator: sepStyle,
+		Ellipsis:       sepStyle,
+		FullKey:        keyStyle,
+		FullDesc:       descStyle,
+		FullSeparator:  sepStyle,
+	}
+}
@@ -1,6 +1,9 @@
 package types
 
-import "time"
+import (
+	"math"
+	"time"
+)
 
 type SearchRequest struct {
 	Origin      string
@@ -8,6 +11,9 @@ type SearchRequest struct {
 	DepartDate  time.Time
 	ReturnDate  *time.Time
 	Adults      int
+	Children    int
+	Infants     int
+	Cabin       string // economy, premium_economy, business or first
 	MaxResults  int
 	Currency    string
 }
@@ -15,10 +21,91 @@ type SearchRequest struct {
 type FlightOffer struct {
 	Provider   string
 	OfferID    string
-	TotalPrice Money
+	TotalPrice Money       // as the provider quoted it
+	Converted  *Conversion `json:",omitempty"`
+	Fare       *Fare       `json:",omitempty"` // nil when the provider gives no breakdown
+	Baggage    *Baggage    `json:",omitempty"` // nil when the provider doesn't say
+	Estimate   *Estimate   `json:",omitempty"`
+	Emissions  *Emissions  `json:",omitempty"` // nil when neither reported nor estimated
 	Segments   []Segment
 }
 
+// Emissions is the CO2 of one traveler's trip.
+type Emissions struct {
+	Grams        int
+	TypicalGrams int  // for the route; 0 when unknown
+	Estimated    bool // worked out by flyctl rather than reported
+}
+
+// VsTypical is how much more (or, when negative, less) CO2 the trip emits
+// than is typical for the route, in percent.
+func (e Emissions) VsTypical() (int, bool) {
+	if e.TypicalGrams <= 0 {
+		return 0, false
+	}
+	return int(math.Round(float64(e.Grams-e.TypicalGrams) * 100 / float64(e.TypicalGrams))), true
+}
+
+// Baggage is the luggage each traveler may bring at no extra cost.
+type Baggage struct {
+	CarryOn int
+	Checked int
+}
+
+// Estimate is what an offer comes to once the bags you travel with are
+// paid for, in the display currency.
+type Estimate struct {
+	Total    Money
+	BagFees  Money
+	Complete bool   // false when the allowance or the carrier's fees are unknown
+	Table    string // the fee table entry used, e.g. "Air Canada"
+	Luggage  string // the bags priced, e.g. "1 carry-on, 1 checked"
+}
+
+// Fare is how an offer's price breaks down, in the quoted currency.
+type Fare struct {
+	Base       Money
+	Taxes      Money
+	Fees       Money
+	GrandTotal Money // the total plus extras paid at booking
+	Travelers  []TravelerFare
+}
+
+// TravelerFare is what one passenger's ticket costs.
+type TravelerFare struct {
+	ID    string
+	Type  string // adult, child, senior, young, seated_infant or held_infant
+	Base  Money
+	Taxes Money
+	Total Money
+}
+
+// Conversion is an offer's price in the display currency.
+type Conversion struct {
+	Price    Money
+	Rate     string    // display currency per unit of the quoted one
+	RateDate time.Time // the day the rate was published
+	Source   string    // where the rate came from
+}
+
+// EstimatedTotal is the price with bag fees, or the price alone when there
+// is no estimate.
+func (o FlightOffer) EstimatedTotal() Money {
+	if o.Estimate != nil {
+		return o.Estimate.Total
+	}
+	return o.Price()
+}
+
+// Price is what the offer costs in the display currency, when it could be
+// converted, and as quoted otherwise.
+func (o FlightOffer) Price() Money {
+	if o.Converted != nil {
+		return o.Converted.Price
+	}
+	return o.TotalPrice
+}
+
 type Segment struct {
 	From     string
 	To       string
@@ -27,6 +114,14 @@ type Segment struct {
 	Carrier  string
 	FlightNo string
 	Cabin    string
+	Aircraft string // a model name, or an IATA type code such as "32N"
+	Legroom  string // e.g. "31 in"
+	Seat     string // e.g. "Extra reclining seat"
+
+	FromTerminal string
+	ToTerminal   string
+	Amenities    []string // as the provider words them, e.g. "Wi-Fi for a fee"
+	SelfTransfer bool     // ticketed separately from the segment before
 }
 
 type Money struct {
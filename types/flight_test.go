@@ -0,0 +1,28 @@
+package types
+
+import "testing"
+
+func TestVsTypical(t *testing.T) {
+	tests := []struct {
+		grams, typical int
+		want           int
+		ok             bool
+	}{
+		{110_000, 100_000, 10, true},
+		{90_000, 100_000, -10, true},
+		{100_000, 100_000, 0, true},
+		{250_000, 100_000, 150, true},
+		// whole percent, half away from zero
+		{100_500, 100_000, 1, true},
+		{100_499, 100_000, 0, true},
+		{99_500, 100_000, -1, true},
+		{100_000, 0, 0, false},
+		{100_000, -5, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := Emissions{Grams: tt.grams, TypicalGrams: tt.typical}.VsTypical()
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("VsTypical(%d g vs %d g) = %d, %v, want %d, %v", tt.grams, tt.typical, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/charmbracelet/bubbles/table"
+	"github.com/justinm35/flyctl/money"
 	"github.com/justinm35/flyctl/types"
 )
 
@@ -63,10 +64,27 @@ func FormatResponseData(offers []types.FlightOffer) []table.Row {
 		}
 
 		// -------- Price (Money is minor units)
-		totalPrice := formatMoney(o.TotalPrice)
+		totalPrice := FormatMoney(o.Price())
+		if o.Converted != nil {
+			totalPrice = "≈" + totalPrice
+		}
+
+		// -------- Estimated total with bag fees; "+?" when some fees are unknown
+		estimatedTotal := FormatMoney(o.EstimatedTotal())
+		if o.Estimate != nil && !o.Estimate.Complete {
+			estimatedTotal += " +?"
+		}
+
+		// -------- CO2 per traveler with the "vs typical" badge
+		co2 := FormatEmissions(o.Emissions)
+		if o.Emissions != nil {
+			if diff, ok := o.Emissions.VsTypical(); ok {
+				co2 += " " + EmissionsBadge(diff)
+			}
+		}
 
 		// -------- Carrier (choose unique carriers encountered)
-		carrierString := joinUniqueCarriers(o.Segments)
+		carrierString := JoinUniqueCarriers(o.Segments)
 
 		// -------- Seats remaining (not in types yet)
 		// seatsRemaining := "-" // you removed it from types.FlightOffer
@@ -78,6 +96,9 @@ func FormatResponseData(offers []types.FlightOffer) []table.Row {
 			arrivalTime,
 			totalDurationString,
 			totalPrice,
+			estimatedTotal,
+			co2,
+			"", // connection warnings, filled in by the caller
 			carrierString,
 			// seatsRemaining,
 		})
@@ -99,20 +120,39 @@ func formatDuration(d time.Duration) string {
 	return fmt.Sprintf("%dh %dm", h, m)
 }
 
-func formatMoney(m types.Money) string {
-	// assumes 2dp; matches your adapter parseMoneyMinorUnits(..., 2)
-	abs := m.Amount
-	sign := ""
-	if abs < 0 {
-		sign = "-"
-		abs = -abs
+// FormatEmissions renders CO2 in kilograms, with "~" when flyctl estimated
+// it, e.g. "~182 kg".
+func FormatEmissions(e *types.Emissions) string {
+	if e == nil {
+		return "-"
 	}
-	major := abs / 100
-	minor := abs % 100
-	return fmt.Sprintf("%s%s %d.%02d", sign, m.Currency, major, minor)
+	s := fmt.Sprintf("%d kg", (e.Grams+500)/1000)
+	if e.Estimated {
+		s = "~" + s
+	}
+	return s
+}
+
+// EmissionsBadge renders a difference from the typical emissions of a
+// route, e.g. "-12%", "+20%" or "avg".
+func EmissionsBadge(diff int) string {
+	switch {
+	case diff == 0:
+		return "avg"
+	case diff > 0:
+		return fmt.Sprintf("+%d%%", diff)
+	default:
+		return fmt.Sprintf("%d%%", diff)
+	}
+}
+
+// FormatMoney renders an amount in the user's locale, e.g. "CA$123.45".
+func FormatMoney(m types.Money) string {
+	return money.Format(m)
 }
 
-func joinUniqueCarriers(segs []types.Segment) string {
+// JoinUniqueCarriers lists the carriers of the segments once each, in order.
+func JoinUniqueCarriers(segs []types.Segment) string {
 	seen := map[string]struct{}{}
 	var carriers []string
 	for _, s := range segs {